@@ -1,23 +1,38 @@
 package contenttype
 
-import (
-	"net/http"
-	"slices"
-)
+import "net/http"
 
+// New rejects request bodies whose Content-Type doesn't match one of
+// contentTypes. Rules may use wildcards like "text/*" and parameters like
+// "charset=utf-8", which must then be present in the request.
 func New(contentTypes ...string) func(http.Handler) http.Handler {
+	rules := mustParseMediaTypes(contentTypes)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.ContentLength == 0 {
+			if !hasBody(r) {
 				next.ServeHTTP(w, r)
 				return
 			}
-			contentType := r.Header.Get("content-type")
-			if slices.Contains(contentTypes, contentType) {
-				next.ServeHTTP(w, r)
+			if status := check(r, rules); status != http.StatusOK {
+				w.WriteHeader(status)
 				return
 			}
-			w.WriteHeader(http.StatusUnsupportedMediaType)
+			next.ServeHTTP(w, r)
 		})
 	}
 }
+
+func check(r *http.Request, rules []mediaType) int {
+	header := r.Header.Get("content-type")
+	if header == "" {
+		return http.StatusUnsupportedMediaType
+	}
+	contentType, err := parseMediaType(header)
+	if err != nil {
+		return http.StatusBadRequest
+	}
+	if !matchesAny(rules, contentType) {
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusOK
+}
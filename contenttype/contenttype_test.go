@@ -55,6 +55,76 @@ func TestContentType(t *testing.T) {
 			expectedCode: http.StatusOK,
 			hasBody:      false,
 		},
+		{
+			name:                "content type with parameters should return 200",
+			allowedContentTypes: []string{"application/json"},
+			contentType:         "application/json; charset=utf-8",
+			expectedCode:        http.StatusOK,
+			hasBody:             true,
+		},
+		{
+			name:                "content type in different case should return 200",
+			allowedContentTypes: []string{"application/json"},
+			contentType:         "Application/JSON",
+			expectedCode:        http.StatusOK,
+			hasBody:             true,
+		},
+		{
+			name:                "content type matching wildcard should return 200",
+			allowedContentTypes: []string{"text/*"},
+			contentType:         "text/csv",
+			expectedCode:        http.StatusOK,
+			hasBody:             true,
+		},
+		{
+			name:                "content type not matching wildcard should return 415",
+			allowedContentTypes: []string{"text/*"},
+			contentType:         "image/png",
+			expectedCode:        http.StatusUnsupportedMediaType,
+			hasBody:             true,
+		},
+		{
+			name:                "content type matching parameter constraint should return 200",
+			allowedContentTypes: []string{"text/plain; charset=utf-8"},
+			contentType:         "text/plain; charset=UTF-8",
+			expectedCode:        http.StatusOK,
+			hasBody:             true,
+		},
+		{
+			name:                "content type violating parameter constraint should return 415",
+			allowedContentTypes: []string{"text/plain; charset=utf-8"},
+			contentType:         "text/plain; charset=latin1",
+			expectedCode:        http.StatusUnsupportedMediaType,
+			hasBody:             true,
+		},
+		{
+			name:                "content type missing parameter constraint should return 415",
+			allowedContentTypes: []string{"text/plain; charset=utf-8"},
+			contentType:         "text/plain",
+			expectedCode:        http.StatusUnsupportedMediaType,
+			hasBody:             true,
+		},
+		{
+			name:                "missing content type with request body should return 415",
+			allowedContentTypes: []string{"application/json"},
+			contentType:         "",
+			expectedCode:        http.StatusUnsupportedMediaType,
+			hasBody:             true,
+		},
+		{
+			name:                "malformed content type should return 400",
+			allowedContentTypes: []string{"application/json"},
+			contentType:         "application/json; charset",
+			expectedCode:        http.StatusBadRequest,
+			hasBody:             true,
+		},
+		{
+			name:                "content type without subtype should return 400",
+			allowedContentTypes: []string{"application/json"},
+			contentType:         "json",
+			expectedCode:        http.StatusBadRequest,
+			hasBody:             true,
+		},
 	}
 
 	for _, test := range tests {
@@ -69,7 +139,9 @@ func TestContentType(t *testing.T) {
 				body = strings.NewReader("body!")
 			}
 			req := httptest.NewRequest(http.MethodPost, "/", body)
-			req.Header.Add("content-type", test.contentType)
+			if test.contentType != "" {
+				req.Header.Add("content-type", test.contentType)
+			}
 			w := httptest.NewRecorder()
 			wrapped.ServeHTTP(w, req)
 
@@ -79,3 +151,14 @@ func TestContentType(t *testing.T) {
 		})
 	}
 }
+
+func TestInvalidContentTypeRule(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected invalid rule to panic")
+		}
+	}()
+	contenttype.New("not a media type")
+}
@@ -0,0 +1,141 @@
+package contenttype
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/fivethirty/middest/errs"
+)
+
+// maxEncodings bounds stacked codings, since every layer multiplies the work
+// a small body can cause before any byte limit applies.
+const maxEncodings = 2
+
+type decoder func(io.Reader) (io.ReadCloser, error)
+
+var decoders = map[string]decoder{
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": zlib.NewReader,
+}
+
+type decompressConfig struct {
+	encodings  []string
+	writeError errs.ErrorWriter
+}
+
+type DecompressOption func(*decompressConfig)
+
+// WithEncodings limits the accepted encodings to a subset of "gzip" and
+// "deflate". Identity is always accepted.
+func WithEncodings(encodings ...string) DecompressOption {
+	for _, encoding := range encodings {
+		if _, ok := decoders[encoding]; !ok {
+			panic(fmt.Sprintf("contenttype: unsupported encoding %q", encoding))
+		}
+	}
+	return func(c *decompressConfig) {
+		c.encodings = encodings
+	}
+}
+
+func WithUnsupportedEncodingWriter(writeError errs.ErrorWriter) DecompressOption {
+	return func(c *decompressConfig) {
+		c.writeError = writeError
+	}
+}
+
+// Decompress decodes request bodies sent with a Content-Encoding so the next
+// handler sees plain bodies. Reading more than maxBytes of decoded body fails
+// with an *http.MaxBytesError, however small the encoded body was.
+func Decompress(maxBytes int64, opts ...DecompressOption) func(http.Handler) http.Handler {
+	c := &decompressConfig{
+		encodings: []string{"gzip", "deflate"},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encodings := c.parseEncodings(r.Header.Values("Content-Encoding"))
+			if encodings == nil {
+				w.Header().Set("Accept-Encoding", strings.Join(c.encodings, ", "))
+				c.fail(w, r, http.StatusUnsupportedMediaType)
+				return
+			}
+			r.Header.Del("Content-Encoding")
+			if len(encodings) == 0 || !hasBody(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Encodings are listed in the order they were applied.
+			var body io.Reader = r.Body
+			closers := closers{r.Body}
+			for i := len(encodings) - 1; i >= 0; i-- {
+				decoded, err := decoders[encodings[i]](body)
+				if err != nil {
+					_ = closers.Close()
+					c.fail(w, r, readErrorStatus(err))
+					return
+				}
+				body = decoded
+				closers = append(closers, decoded)
+			}
+
+			r.Body = http.MaxBytesReader(w, readCloser{Reader: body, Closer: closers}, maxBytes)
+			r.Header.Del("Content-Length")
+			r.ContentLength = -1
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// parseEncodings returns the encodings to undo without identity, or nil if
+// any of them isn't accepted or there are more than maxEncodings.
+func (c *decompressConfig) parseEncodings(headers []string) []string {
+	encodings := []string{}
+	for _, header := range headers {
+		for encoding := range strings.SplitSeq(header, ",") {
+			encoding = strings.ToLower(strings.TrimSpace(encoding))
+			switch encoding {
+			case "", "identity":
+				continue
+			case "x-gzip":
+				encoding = "gzip"
+			}
+			if !slices.Contains(c.encodings, encoding) || len(encodings) == maxEncodings {
+				return nil
+			}
+			encodings = append(encodings, encoding)
+		}
+	}
+	return encodings
+}
+
+func (c *decompressConfig) fail(w http.ResponseWriter, r *http.Request, status int) {
+	if c.writeError != nil {
+		c.writeError(w, r, status, "")
+		return
+	}
+	w.WriteHeader(status)
+}
+
+// closers closes decoders before the body they read from.
+type closers []io.Closer
+
+func (cs closers) Close() error {
+	closeErrs := []error{}
+	for i := len(cs) - 1; i >= 0; i-- {
+		closeErrs = append(closeErrs, cs[i].Close())
+	}
+	return errors.Join(closeErrs...)
+}
@@ -0,0 +1,244 @@
+package contenttype_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fivethirty/middest/contenttype"
+	"github.com/fivethirty/middest/errs"
+)
+
+func gzipped(t *testing.T, body []byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	if _, err := writer.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func deflated(t *testing.T, body []byte) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := zlib.NewWriter(&buffer)
+	if _, err := writer.Write(body); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestDecompress(t *testing.T) {
+	t.Parallel()
+
+	plain := []byte(`{"name":"avatar"}`)
+	bomb := gzipped(t, bytes.Repeat([]byte("a"), 1<<20))
+
+	tests := []struct {
+		name                   string
+		opts                   []contenttype.DecompressOption
+		contentEncoding        []string
+		body                   []byte
+		expectedCode           int
+		expectedBody           []byte
+		expectedTooLarge       bool
+		expectedAcceptEncoding string
+	}{
+		{
+			name:         "no encoding should pass through",
+			body:         plain,
+			expectedCode: http.StatusOK,
+			expectedBody: plain,
+		},
+		{
+			name:            "identity should pass through",
+			contentEncoding: []string{"identity"},
+			body:            plain,
+			expectedCode:    http.StatusOK,
+			expectedBody:    plain,
+		},
+		{
+			name:            "gzip should be decoded",
+			contentEncoding: []string{"gzip"},
+			body:            gzipped(t, plain),
+			expectedCode:    http.StatusOK,
+			expectedBody:    plain,
+		},
+		{
+			name:            "x-gzip should be decoded",
+			contentEncoding: []string{"X-GZIP"},
+			body:            gzipped(t, plain),
+			expectedCode:    http.StatusOK,
+			expectedBody:    plain,
+		},
+		{
+			name:            "deflate should be decoded",
+			contentEncoding: []string{"deflate"},
+			body:            deflated(t, plain),
+			expectedCode:    http.StatusOK,
+			expectedBody:    plain,
+		},
+		{
+			name:            "stacked encodings should be decoded in reverse",
+			contentEncoding: []string{"deflate, gzip"},
+			body:            gzipped(t, deflated(t, plain)),
+			expectedCode:    http.StatusOK,
+			expectedBody:    plain,
+		},
+		{
+			name:                   "too many stacked encodings should be rejected",
+			contentEncoding:        []string{"gzip, gzip", "gzip"},
+			body:                   gzipped(t, gzipped(t, gzipped(t, plain))),
+			expectedCode:           http.StatusUnsupportedMediaType,
+			expectedAcceptEncoding: "gzip, deflate",
+		},
+		{
+			name:                   "unsupported encoding should be rejected",
+			contentEncoding:        []string{"br"},
+			body:                   plain,
+			expectedCode:           http.StatusUnsupportedMediaType,
+			expectedAcceptEncoding: "gzip, deflate",
+		},
+		{
+			name: "encoding outside allowlist should be rejected",
+			opts: []contenttype.DecompressOption{
+				contenttype.WithEncodings("gzip"),
+			},
+			contentEncoding:        []string{"deflate"},
+			body:                   deflated(t, plain),
+			expectedCode:           http.StatusUnsupportedMediaType,
+			expectedAcceptEncoding: "gzip",
+		},
+		{
+			name:            "corrupt gzip header should be rejected",
+			contentEncoding: []string{"gzip"},
+			body:            plain,
+			expectedCode:    http.StatusBadRequest,
+		},
+		{
+			name:             "decoded body over limit should fail to read",
+			contentEncoding:  []string{"gzip"},
+			body:             bomb,
+			expectedCode:     http.StatusOK,
+			expectedTooLarge: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var body []byte
+			var readErr error
+			handler := contenttype.Decompress(1024, test.opts...)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.Header.Get("Content-Encoding") != "" {
+						t.Errorf("expected Content-Encoding to be removed")
+					}
+					body, readErr = io.ReadAll(r.Body)
+				}),
+			)
+
+			r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(test.body))
+			for _, encoding := range test.contentEncoding {
+				r.Header.Add("Content-Encoding", encoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("Accept-Encoding"); got != test.expectedAcceptEncoding {
+				t.Errorf("expected Accept-Encoding %q, got %q", test.expectedAcceptEncoding, got)
+			}
+			if test.expectedBody != nil && !bytes.Equal(body, test.expectedBody) {
+				t.Errorf("expected body %q, got %q", test.expectedBody, body)
+			}
+			var maxBytesErr *http.MaxBytesError
+			if tooLarge := errors.As(readErr, &maxBytesErr); tooLarge != test.expectedTooLarge {
+				t.Errorf("expected too large %v, got error %v", test.expectedTooLarge, readErr)
+			}
+		})
+	}
+}
+
+func TestDecompressUnsupportedEncodingWriter(t *testing.T) {
+	t.Parallel()
+
+	handler := contenttype.Decompress(
+		1024,
+		contenttype.WithUnsupportedEncodingWriter(errs.WritePlainText),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("expected handler not to be called")
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("body"))
+	r.Header.Set("Content-Encoding", "br")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("expected status code %d, got %d", http.StatusUnsupportedMediaType, w.Code)
+	}
+	if w.Body.Len() == 0 {
+		t.Errorf("expected error body")
+	}
+}
+
+func TestInvalidEncodingOption(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic")
+		}
+	}()
+	contenttype.WithEncodings("br")
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (cr *closeRecorder) Close() error {
+	cr.closed = true
+	return nil
+}
+
+func TestDecompressClosesBody(t *testing.T) {
+	t.Parallel()
+
+	body := &closeRecorder{Reader: bytes.NewReader(gzipped(t, []byte("plain")))}
+	handler := contenttype.Decompress(1024)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.Body.Close(); err != nil {
+				t.Errorf("expected close to succeed, got %v", err)
+			}
+		}),
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/", body)
+	r.Body = body
+	r.ContentLength = -1
+	r.Header.Set("Content-Encoding", "gzip")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !body.closed {
+		t.Errorf("expected original body to be closed")
+	}
+}
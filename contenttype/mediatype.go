@@ -0,0 +1,65 @@
+package contenttype
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+)
+
+type mediaType struct {
+	typ     string
+	subtype string
+	params  map[string]string
+}
+
+func parseMediaType(s string) (mediaType, error) {
+	full, params, err := mime.ParseMediaType(s)
+	if err != nil {
+		return mediaType{}, err
+	}
+	typ, subtype, ok := strings.Cut(full, "/")
+	if !ok || typ == "" || subtype == "" {
+		return mediaType{}, fmt.Errorf("mime: expected type/subtype, got %q", full)
+	}
+	return mediaType{
+		typ:     typ,
+		subtype: subtype,
+		params:  params,
+	}, nil
+}
+
+func mustParseMediaTypes(mediaTypes []string) []mediaType {
+	parsed := make([]mediaType, 0, len(mediaTypes))
+	for _, s := range mediaTypes {
+		mt, err := parseMediaType(s)
+		if err != nil {
+			panic(fmt.Sprintf("contenttype: invalid media type %q: %v", s, err))
+		}
+		parsed = append(parsed, mt)
+	}
+	return parsed
+}
+
+func (rule mediaType) matches(mt mediaType) bool {
+	if rule.typ != "*" && rule.typ != mt.typ {
+		return false
+	}
+	if rule.subtype != "*" && rule.subtype != mt.subtype {
+		return false
+	}
+	for key, value := range rule.params {
+		if !strings.EqualFold(mt.params[key], value) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesAny(rules []mediaType, mt mediaType) bool {
+	for _, rule := range rules {
+		if rule.matches(mt) {
+			return true
+		}
+	}
+	return false
+}
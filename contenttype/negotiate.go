@@ -0,0 +1,132 @@
+package contenttype
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fivethirty/middest/errs"
+)
+
+type contextKey string
+
+const negotiatedKey contextKey = "negotiated_content_type"
+
+type acceptRange struct {
+	mediaType
+	quality     float64
+	specificity int
+}
+
+type negotiateConfig struct {
+	writeError errs.ErrorWriter
+}
+
+type NegotiateOption func(*negotiateConfig)
+
+func WithNotAcceptableWriter(writeError errs.ErrorWriter) NegotiateOption {
+	return func(c *negotiateConfig) {
+		c.writeError = writeError
+	}
+}
+
+// Negotiate picks the offer the client's Accept header prefers, breaking
+// ties in the order of offers, and stores it for Negotiated.
+func Negotiate(offers []string, opts ...NegotiateOption) func(http.Handler) http.Handler {
+	c := &negotiateConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	parsedOffers := mustParseMediaTypes(offers)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept")
+
+			i := negotiate(r.Header.Values("Accept"), parsedOffers)
+			if i < 0 {
+				if c.writeError != nil {
+					c.writeError(w, r, http.StatusNotAcceptable, "")
+				} else {
+					w.WriteHeader(http.StatusNotAcceptable)
+				}
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), negotiatedKey, offers[i])
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func Negotiated(ctx context.Context) string {
+	negotiated, _ := ctx.Value(negotiatedKey).(string)
+	return negotiated
+}
+
+func negotiate(accept []string, offers []mediaType) int {
+	if len(offers) == 0 {
+		return -1
+	}
+	ranges := parseAccept(accept)
+	if len(ranges) == 0 {
+		return 0
+	}
+
+	best, bestQuality := -1, 0.0
+	for i, offer := range offers {
+		quality, specificity := 0.0, -1
+		for _, ar := range ranges {
+			if ar.matches(offer) && ar.specificity > specificity {
+				quality, specificity = ar.quality, ar.specificity
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = i, quality
+		}
+	}
+	return best
+}
+
+func parseAccept(accept []string) []acceptRange {
+	ranges := []acceptRange{}
+	for _, header := range accept {
+		for part := range strings.SplitSeq(header, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			if part == "*" {
+				part = "*/*"
+			}
+			mt, err := parseMediaType(part)
+			if err != nil {
+				continue
+			}
+
+			quality := 1.0
+			if q, ok := mt.params["q"]; ok {
+				quality, err = strconv.ParseFloat(q, 64)
+				if err != nil || quality < 0 || quality > 1 {
+					continue
+				}
+				delete(mt.params, "q")
+			}
+
+			specificity := len(mt.params) + 2
+			switch {
+			case mt.typ == "*":
+				specificity = 0
+			case mt.subtype == "*":
+				specificity = 1
+			}
+			ranges = append(ranges, acceptRange{
+				mediaType:   mt,
+				quality:     quality,
+				specificity: specificity,
+			})
+		}
+	}
+	return ranges
+}
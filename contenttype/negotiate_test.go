@@ -0,0 +1,147 @@
+package contenttype_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fivethirty/middest/contenttype"
+	"github.com/fivethirty/middest/errs"
+)
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		offers       []string
+		accept       []string
+		expectedCode int
+		expected     string
+	}{
+		{
+			name:         "missing Accept should pick first offer",
+			offers:       []string{"text/html", "application/json"},
+			expectedCode: http.StatusOK,
+			expected:     "text/html",
+		},
+		{
+			name:         "exact match should be picked",
+			offers:       []string{"text/html", "application/json"},
+			accept:       []string{"application/json"},
+			expectedCode: http.StatusOK,
+			expected:     "application/json",
+		},
+		{
+			name:         "higher q-value should win",
+			offers:       []string{"text/html", "application/json"},
+			accept:       []string{"text/html;q=0.5, application/json;q=0.9"},
+			expectedCode: http.StatusOK,
+			expected:     "application/json",
+		},
+		{
+			name:         "ties should follow offer order",
+			offers:       []string{"application/json", "text/html"},
+			accept:       []string{"text/html, application/json"},
+			expectedCode: http.StatusOK,
+			expected:     "application/json",
+		},
+		{
+			name:         "more specific range should override wildcard",
+			offers:       []string{"text/html", "text/plain"},
+			accept:       []string{"text/*;q=0.8, text/html;q=0.1"},
+			expectedCode: http.StatusOK,
+			expected:     "text/plain",
+		},
+		{
+			name:         "browser Accept should pick HTML",
+			offers:       []string{"application/json", "text/html"},
+			accept:       []string{"text/html,application/xhtml+xml,*/*;q=0.8"},
+			expectedCode: http.StatusOK,
+			expected:     "text/html",
+		},
+		{
+			name:         "multiple Accept headers should be combined",
+			offers:       []string{"text/html", "application/json"},
+			accept:       []string{"image/png", "application/json"},
+			expectedCode: http.StatusOK,
+			expected:     "application/json",
+		},
+		{
+			name:         "q=0 should exclude offer",
+			offers:       []string{"text/html", "application/json"},
+			accept:       []string{"*/*, text/html;q=0"},
+			expectedCode: http.StatusOK,
+			expected:     "application/json",
+		},
+		{
+			name:         "case should be ignored",
+			offers:       []string{"application/json"},
+			accept:       []string{"Application/JSON"},
+			expectedCode: http.StatusOK,
+			expected:     "application/json",
+		},
+		{
+			name:         "no acceptable offer should return 406",
+			offers:       []string{"text/html", "application/json"},
+			accept:       []string{"image/png"},
+			expectedCode: http.StatusNotAcceptable,
+		},
+		{
+			name:         "malformed ranges should be ignored",
+			offers:       []string{"text/html"},
+			accept:       []string{"nonsense;;, text/html;q=abc, text/*"},
+			expectedCode: http.StatusOK,
+			expected:     "text/html",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var negotiated string
+			wrapped := contenttype.Negotiate(test.offers)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					negotiated = contenttype.Negotiated(r.Context())
+				}),
+			)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, accept := range test.accept {
+				req.Header.Add("Accept", accept)
+			}
+			w := httptest.NewRecorder()
+			wrapped.ServeHTTP(w, req)
+
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+			if negotiated != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, negotiated)
+			}
+			if w.Header().Get("Vary") != "Accept" {
+				t.Errorf("expected Vary: Accept, got %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestNegotiateNotAcceptableWriter(t *testing.T) {
+	t.Parallel()
+
+	wrapped := contenttype.Negotiate(
+		[]string{"text/html"},
+		contenttype.WithNotAcceptableWriter(errs.WritePlainText),
+	)(http.NotFoundHandler())
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	wrapped.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotAcceptable {
+		t.Errorf("expected status code %d, got %d", http.StatusNotAcceptable, w.Code)
+	}
+	if w.Body.String() != "Not Acceptable\n" {
+		t.Errorf("expected body from writer, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,86 @@
+package contenttype
+
+import (
+	"net/http"
+
+	"github.com/fivethirty/middest/internal/route"
+)
+
+type Policy struct {
+	methods    map[string][]mediaType
+	routes     map[string][]mediaType
+	rejectBody map[string]bool
+}
+
+// NewPolicy starts with form bodies for POST and JSON bodies for PUT and
+// PATCH. Methods without a rule accept any content type.
+func NewPolicy() *Policy {
+	return (&Policy{
+		methods:    map[string][]mediaType{},
+		routes:     map[string][]mediaType{},
+		rejectBody: map[string]bool{},
+	}).
+		Method(http.MethodPost, "application/x-www-form-urlencoded", "multipart/form-data").
+		Method(http.MethodPut, "application/json").
+		Method(http.MethodPatch, "application/json")
+}
+
+func (p *Policy) Method(method string, contentTypes ...string) *Policy {
+	p.methods[method] = mustParseMediaTypes(contentTypes)
+	return p
+}
+
+// Route overrides the method rules for requests matching the ServeMux
+// pattern, e.g. "PUT /items/{id}".
+func (p *Policy) Route(pattern string, contentTypes ...string) *Policy {
+	p.routes[pattern] = mustParseMediaTypes(contentTypes)
+	return p
+}
+
+func (p *Policy) RejectBody(methods ...string) *Policy {
+	for _, method := range methods {
+		p.rejectBody[method] = true
+	}
+	return p
+}
+
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !hasBody(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if p.rejectBody[r.Method] {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		rules, ok := p.routes[route.Pattern(r, next)]
+		if !ok {
+			rules, ok = p.methods[r.Method]
+		}
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if status := check(r, rules); status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// hasBody treats an unknown length, as sent with chunked encoding, as a body
+// unless the server already knows it's empty.
+func hasBody(r *http.Request) bool {
+	switch {
+	case r.ContentLength > 0:
+		return true
+	case r.ContentLength < 0:
+		return r.Body != nil && r.Body != http.NoBody
+	default:
+		return false
+	}
+}
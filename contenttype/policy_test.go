@@ -0,0 +1,187 @@
+package contenttype_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fivethirty/middest/contenttype"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		contentType   string
+		body          string
+		contentLength int64
+		expectedCode  int
+	}{
+		{
+			name:         "POST form should be accepted by default",
+			method:       http.MethodPost,
+			path:         "/items",
+			contentType:  "application/x-www-form-urlencoded",
+			body:         "name=a",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "POST multipart should be accepted by default",
+			method:       http.MethodPost,
+			path:         "/items",
+			contentType:  "multipart/form-data; boundary=x",
+			body:         "--x--",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "POST JSON should be rejected by default",
+			method:       http.MethodPost,
+			path:         "/items",
+			contentType:  "application/json",
+			body:         "{}",
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "PUT JSON should be accepted by default",
+			method:       http.MethodPut,
+			path:         "/items/1",
+			contentType:  "application/json",
+			body:         "{}",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "PATCH form should be rejected by default",
+			method:       http.MethodPatch,
+			path:         "/items/1",
+			contentType:  "application/x-www-form-urlencoded",
+			body:         "name=a",
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "route rule should override method rule",
+			method:       http.MethodPost,
+			path:         "/api/items",
+			contentType:  "application/json",
+			body:         "{}",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "route rule should reject other content types",
+			method:       http.MethodPost,
+			path:         "/api/items",
+			contentType:  "application/x-www-form-urlencoded",
+			body:         "name=a",
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "method without rule should accept anything",
+			method:       http.MethodOptions,
+			path:         "/upload",
+			contentType:  "image/png",
+			body:         "png",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "GET with body should be rejected",
+			method:       http.MethodGet,
+			path:         "/items",
+			contentType:  "application/json",
+			body:         "{}",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "GET without body should be accepted",
+			method:       http.MethodGet,
+			path:         "/items",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:          "chunked body should be checked",
+			method:        http.MethodPut,
+			path:          "/items/1",
+			contentType:   "text/plain",
+			body:          "chunked",
+			contentLength: -1,
+			expectedCode:  http.StatusUnsupportedMediaType,
+		},
+		{
+			name:          "unknown length without body should be accepted",
+			method:        http.MethodPut,
+			path:          "/items/1",
+			contentType:   "text/plain",
+			contentLength: -1,
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:         "malformed content type should return 400",
+			method:       http.MethodPut,
+			path:         "/items/1",
+			contentType:  "application/json; charset",
+			body:         "{}",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items", ok)
+	mux.HandleFunc("POST /items", ok)
+	mux.HandleFunc("PUT /items/{id}", ok)
+	mux.HandleFunc("PATCH /items/{id}", ok)
+	mux.HandleFunc("POST /api/items", ok)
+	mux.HandleFunc("OPTIONS /upload", ok)
+
+	policy := contenttype.NewPolicy().
+		Route("POST /api/items", "application/json").
+		RejectBody(http.MethodGet, http.MethodDelete)
+	handler := policy.Middleware(mux)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var body io.Reader
+			if test.body != "" {
+				body = strings.NewReader(test.body)
+			}
+			req := httptest.NewRequest(test.method, test.path, body)
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			if test.contentLength != 0 {
+				req.ContentLength = test.contentLength
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestPolicyRoutePattern(t *testing.T) {
+	t.Parallel()
+
+	policy := contenttype.NewPolicy().Route("POST /api/items", "application/json")
+	mux := http.NewServeMux()
+	mux.Handle(
+		"POST /api/items",
+		policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/items", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,165 @@
+package contenttype
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/fivethirty/middest/internal/route"
+)
+
+var ErrDisallowedContentType = errors.New("contenttype: response content type not allowed")
+
+type responseConfig struct {
+	defaultContentType string
+	routeDefaults      map[string]string
+	allowed            []mediaType
+	logger             *slog.Logger
+	reject             bool
+}
+
+type ResponseOption func(*responseConfig)
+
+func WithRouteDefault(pattern string, contentType string) ResponseOption {
+	return func(c *responseConfig) {
+		c.routeDefaults[pattern] = contentType
+	}
+}
+
+// WithAllowedResponseTypes logs responses whose Content-Type doesn't match
+// one of contentTypes.
+func WithAllowedResponseTypes(logger *slog.Logger, contentTypes ...string) ResponseOption {
+	return func(c *responseConfig) {
+		c.logger = logger
+		c.allowed = mustParseMediaTypes(contentTypes)
+	}
+}
+
+// WithRejectDisallowed replaces responses that WithAllowedResponseTypes
+// would log with an empty 500.
+func WithRejectDisallowed() ResponseOption {
+	return func(c *responseConfig) {
+		c.reject = true
+	}
+}
+
+// Response sets X-Content-Type-Options: nosniff and gives responses without
+// a Content-Type defaultContentType, or the default for their route, so that
+// net/http never has to sniff it.
+func Response(defaultContentType string, opts ...ResponseOption) func(http.Handler) http.Handler {
+	c := &responseConfig{
+		defaultContentType: defaultContentType,
+		routeDefaults:      map[string]string{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+
+			contentType, ok := c.routeDefaults[route.Pattern(r, next)]
+			if !ok {
+				contentType = c.defaultContentType
+			}
+			wrapped := &responseWriter{
+				ResponseWriter:     w,
+				config:             c,
+				request:            r,
+				defaultContentType: contentType,
+			}
+			next.ServeHTTP(wrapped, r)
+			wrapped.commit(false)
+		})
+	}
+}
+
+// responseWriter holds back WriteHeader until the first Write so that the
+// Content-Type can still be set by whatever writes the body.
+type responseWriter struct {
+	http.ResponseWriter
+	config             *responseConfig
+	request            *http.Request
+	defaultContentType string
+	status             int
+	committed          bool
+	rejected           bool
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.committed {
+		rw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code >= 100 && code < 200 {
+		rw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if rw.status == 0 {
+		rw.status = code
+	}
+}
+
+func (rw *responseWriter) Write(body []byte) (int, error) {
+	rw.commit(true)
+	if rw.rejected {
+		return 0, ErrDisallowedContentType
+	}
+	return rw.ResponseWriter.Write(body)
+}
+
+func (rw *responseWriter) Flush() {
+	rw.commit(true)
+	_ = http.NewResponseController(rw.ResponseWriter).Flush()
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+func (rw *responseWriter) commit(hasBody bool) {
+	if rw.committed {
+		return
+	}
+	rw.committed = true
+
+	status := rw.status
+	if status == 0 {
+		if !hasBody {
+			return
+		}
+		status = http.StatusOK
+	}
+
+	header := rw.ResponseWriter.Header()
+	_, hasContentType := header["Content-Type"]
+	if hasBody && !hasContentType && bodyAllowed(status) && rw.defaultContentType != "" {
+		header.Set("Content-Type", rw.defaultContentType)
+	}
+
+	if hasBody && rw.config.allowed != nil && !rw.isAllowed(header.Get("Content-Type")) {
+		rw.config.logger.WarnContext(
+			rw.request.Context(),
+			"Disallowed Content-Type",
+			"content_type", header.Get("Content-Type"),
+			"status", status,
+		)
+		if rw.config.reject {
+			rw.rejected = true
+			header.Del("Content-Type")
+			status = http.StatusInternalServerError
+		}
+	}
+
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseWriter) isAllowed(contentType string) bool {
+	mt, err := parseMediaType(contentType)
+	return err == nil && matchesAny(rw.config.allowed, mt)
+}
+
+func bodyAllowed(status int) bool {
+	return status != http.StatusNoContent && status != http.StatusNotModified
+}
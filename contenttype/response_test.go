@@ -0,0 +1,180 @@
+package contenttype_test
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fivethirty/middest/contenttype"
+)
+
+func TestResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                string
+		path                string
+		routeDefault        string
+		allowed             []string
+		reject              bool
+		handler             func(w http.ResponseWriter) error
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+		expectedLogs        bool
+		expectedErr         error
+	}{
+		{
+			name: "missing content type should get default",
+			path: "/page",
+			handler: func(w http.ResponseWriter) error {
+				_, err := w.Write([]byte("plain words"))
+				return err
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "plain words",
+		},
+		{
+			name:         "missing content type should get route default",
+			path:         "/api/items",
+			routeDefault: "application/json",
+			handler: func(w http.ResponseWriter) error {
+				_, err := w.Write([]byte("<html>"))
+				return err
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/json",
+			expectedBody:        "<html>",
+		},
+		{
+			name: "content type set before write should be kept",
+			path: "/page",
+			handler: func(w http.ResponseWriter) error {
+				w.Header().Set("Content-Type", "text/plain")
+				_, err := w.Write([]byte("<html>"))
+				return err
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/plain",
+			expectedBody:        "<html>",
+		},
+		{
+			name: "content type set after WriteHeader should be kept",
+			path: "/page",
+			handler: func(w http.ResponseWriter) error {
+				w.WriteHeader(http.StatusNotFound)
+				w.Header().Set("Content-Type", "text/plain")
+				_, err := w.Write([]byte("missing"))
+				return err
+			},
+			expectedCode:        http.StatusNotFound,
+			expectedContentType: "text/plain",
+			expectedBody:        "missing",
+		},
+		{
+			name: "response without body should not get default",
+			path: "/page",
+			handler: func(w http.ResponseWriter) error {
+				w.WriteHeader(http.StatusNoContent)
+				return nil
+			},
+			expectedCode: http.StatusNoContent,
+		},
+		{
+			name:    "disallowed content type should be logged",
+			path:    "/page",
+			allowed: []string{"text/html"},
+			handler: func(w http.ResponseWriter) error {
+				w.Header().Set("Content-Type", "application/xml")
+				_, err := w.Write([]byte("<xml/>"))
+				return err
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "application/xml",
+			expectedBody:        "<xml/>",
+			expectedLogs:        true,
+		},
+		{
+			name:    "disallowed content type should be rejected",
+			path:    "/page",
+			allowed: []string{"text/html"},
+			reject:  true,
+			handler: func(w http.ResponseWriter) error {
+				w.Header().Set("Content-Type", "application/xml")
+				_, err := w.Write([]byte("<xml/>"))
+				return err
+			},
+			expectedCode: http.StatusInternalServerError,
+			expectedLogs: true,
+			expectedErr:  contenttype.ErrDisallowedContentType,
+		},
+		{
+			name:    "allowed content type should not be logged",
+			path:    "/page",
+			allowed: []string{"text/*"},
+			reject:  true,
+			handler: func(w http.ResponseWriter) error {
+				_, err := w.Write([]byte("page"))
+				return err
+			},
+			expectedCode:        http.StatusOK,
+			expectedContentType: "text/html; charset=utf-8",
+			expectedBody:        "page",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := bytes.NewBuffer(nil)
+			opts := []contenttype.ResponseOption{}
+			if test.routeDefault != "" {
+				opts = append(
+					opts,
+					contenttype.WithRouteDefault("GET "+test.path, test.routeDefault),
+				)
+			}
+			if test.allowed != nil {
+				logger := slog.New(slog.NewJSONHandler(buffer, nil))
+				opts = append(opts, contenttype.WithAllowedResponseTypes(logger, test.allowed...))
+			}
+			if test.reject {
+				opts = append(opts, contenttype.WithRejectDisallowed())
+			}
+
+			var err error
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET "+test.path, func(w http.ResponseWriter, r *http.Request) {
+				err = test.handler(w)
+			})
+			handler := contenttype.Response("text/html; charset=utf-8", opts...)(mux)
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+			if got := w.Header().Get("Content-Type"); got != test.expectedContentType {
+				t.Errorf("expected content type %q, got %q", test.expectedContentType, got)
+			}
+			if w.Header().Get("X-Content-Type-Options") != "nosniff" {
+				t.Errorf("expected nosniff to be set")
+			}
+			if w.Body.String() != test.expectedBody {
+				t.Errorf("expected body %q, got %q", test.expectedBody, w.Body.String())
+			}
+			if hasLogs := buffer.Len() > 0; hasLogs != test.expectedLogs {
+				t.Errorf("expected logs %v, got %q", test.expectedLogs, buffer.String())
+			}
+			if !errors.Is(err, test.expectedErr) {
+				t.Errorf("expected error %v, got %v", test.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package contenttype
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"slices"
+)
+
+const (
+	sniffLen                = 512
+	defaultMaxMultipartSize = 32 << 20
+)
+
+type Signature struct {
+	MediaType string
+	Offset    int
+	Magic     []byte
+}
+
+var DefaultSignatures = []Signature{
+	{MediaType: "image/avif", Offset: 4, Magic: []byte("ftypavif")},
+	{MediaType: "image/heic", Offset: 4, Magic: []byte("ftypheic")},
+	{MediaType: "image/tiff", Magic: []byte("II*\x00")},
+	{MediaType: "image/tiff", Magic: []byte("MM\x00*")},
+}
+
+type sniffConfig struct {
+	signatures       []Signature
+	maxMultipartSize int64
+}
+
+type SniffOption func(*sniffConfig)
+
+func WithSignatures(signatures ...Signature) SniffOption {
+	return func(c *sniffConfig) {
+		c.signatures = append(c.signatures, signatures...)
+	}
+}
+
+// WithMaxMultipartSize caps how much of a multipart body Sniff holds in
+// memory while checking its parts. Larger bodies get a 413.
+func WithMaxMultipartSize(maxBytes int64) SniffOption {
+	return func(c *sniffConfig) {
+		c.maxMultipartSize = maxBytes
+	}
+}
+
+// Sniff compares the first bytes of request bodies, and of every part of
+// multipart bodies, with their declared type when it matches contentTypes.
+// The body is restored so the next handler can read it in full.
+func Sniff(contentTypes []string, opts ...SniffOption) func(http.Handler) http.Handler {
+	c := &sniffConfig{
+		signatures:       slices.Clone(DefaultSignatures),
+		maxMultipartSize: defaultMaxMultipartSize,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	rules := mustParseMediaTypes(contentTypes)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !hasBody(r) || r.Header.Get("content-type") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			declared, err := parseMediaType(r.Header.Get("content-type"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			var status int
+			if declared.typ == "multipart" {
+				status = c.sniffMultipart(r, declared, rules)
+			} else if matchesAny(rules, declared) {
+				status = c.sniffBody(r, declared)
+			}
+			if status != 0 {
+				w.WriteHeader(status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (c *sniffConfig) sniffBody(r *http.Request, declared mediaType) int {
+	head, err := io.ReadAll(io.LimitReader(r.Body, sniffLen))
+	r.Body = readCloser{
+		Reader: io.MultiReader(bytes.NewReader(head), r.Body),
+		Closer: r.Body,
+	}
+	if err != nil {
+		return readErrorStatus(err)
+	}
+	if !c.detected(head, declared) {
+		return http.StatusUnsupportedMediaType
+	}
+	return 0
+}
+
+// sniffMultipart has to read up to the last checked part to reach it and
+// keeps what it read so the body can be restored, up to maxMultipartSize.
+func (c *sniffConfig) sniffMultipart(r *http.Request, declared mediaType, rules []mediaType) int {
+	var buffer bytes.Buffer
+	body := io.TeeReader(io.LimitReader(r.Body, c.maxMultipartSize+1), &buffer)
+	status := c.sniffParts(multipart.NewReader(body, declared.params["boundary"]), rules)
+	r.Body = readCloser{
+		Reader: io.MultiReader(&buffer, r.Body),
+		Closer: r.Body,
+	}
+	if int64(buffer.Len()) > c.maxMultipartSize {
+		return http.StatusRequestEntityTooLarge
+	}
+	return status
+}
+
+func (c *sniffConfig) sniffParts(reader *multipart.Reader, rules []mediaType) int {
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			return readErrorStatus(err)
+		}
+
+		header := part.Header.Get("Content-Type")
+		if header == "" {
+			continue
+		}
+		partType, err := parseMediaType(header)
+		if err != nil {
+			return http.StatusBadRequest
+		}
+		if !matchesAny(rules, partType) {
+			continue
+		}
+
+		head, err := io.ReadAll(io.LimitReader(part, sniffLen))
+		if err != nil {
+			return readErrorStatus(err)
+		}
+		if !c.detected(head, partType) {
+			return http.StatusUnsupportedMediaType
+		}
+	}
+}
+
+func (c *sniffConfig) detected(head []byte, declared mediaType) bool {
+	for _, signature := range c.signatures {
+		end := signature.Offset + len(signature.Magic)
+		if end > len(head) || !bytes.Equal(head[signature.Offset:end], signature.Magic) {
+			continue
+		}
+		mt, err := parseMediaType(signature.MediaType)
+		if err == nil && mt.typ == declared.typ && mt.subtype == declared.subtype {
+			return true
+		}
+	}
+
+	mt, err := parseMediaType(http.DetectContentType(head))
+	return err == nil && mt.typ == declared.typ && mt.subtype == declared.subtype
+}
+
+func readErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
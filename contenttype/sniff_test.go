@@ -0,0 +1,206 @@
+package contenttype_test
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/fivethirty/middest/contenttype"
+)
+
+var (
+	png  = []byte("\x89PNG\x0D\x0A\x1A\x0A rest of the image")
+	exe  = []byte("MZ\x90\x00 rest of the executable")
+	avif = []byte("\x00\x00\x00\x1cftypavif rest of the image")
+	fake = []byte("FAKE rest of the file")
+)
+
+type filePart struct {
+	contentType string
+	content     []byte
+}
+
+func multipartBody(t *testing.T, parts ...filePart) ([]byte, string) {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	if err := writer.WriteField("name", "avatar"); err != nil {
+		t.Fatal(err)
+	}
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Disposition", `form-data; name="file"; filename="file"`)
+		header.Set("Content-Type", part.contentType)
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(part.content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes(), writer.FormDataContentType()
+}
+
+func TestSniff(t *testing.T) {
+	t.Parallel()
+
+	multipartOK, multipartOKType := multipartBody(t,
+		filePart{contentType: "image/png", content: png},
+		filePart{contentType: "image/avif", content: avif},
+	)
+	multipartBad, multipartBadType := multipartBody(t,
+		filePart{contentType: "image/png", content: png},
+		filePart{contentType: "image/png", content: exe},
+	)
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         []byte
+		expectedCode int
+	}{
+		{
+			name:         "matching body should return 200",
+			contentType:  "image/png",
+			body:         png,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "mismatching body should return 415",
+			contentType:  "image/png",
+			body:         exe,
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "default signature should match",
+			contentType:  "image/avif",
+			body:         avif,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "custom signature should match",
+			contentType:  "application/x-fake",
+			body:         fake,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unverified content type should return 200",
+			contentType:  "application/octet-stream",
+			body:         exe,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "matching multipart parts should return 200",
+			contentType:  multipartOKType,
+			body:         multipartOK,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "mismatching multipart part should return 415",
+			contentType:  multipartBadType,
+			body:         multipartBad,
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "malformed content type should return 400",
+			contentType:  "image/png; charset",
+			body:         png,
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var read []byte
+			wrapped := contenttype.Sniff(
+				[]string{"image/*", "application/x-fake"},
+				contenttype.WithSignatures(contenttype.Signature{
+					MediaType: "application/x-fake",
+					Magic:     []byte("FAKE"),
+				}),
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var err error
+				read, err = io.ReadAll(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(test.body))
+			req.Header.Set("Content-Type", test.contentType)
+			w := httptest.NewRecorder()
+			wrapped.ServeHTTP(w, req)
+
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+			if test.expectedCode == http.StatusOK && !bytes.Equal(read, test.body) {
+				t.Errorf("expected next handler to read the full body")
+			}
+		})
+	}
+}
+
+func TestSniffMaxMultipartSize(t *testing.T) {
+	t.Parallel()
+
+	body, contentType := multipartBody(t,
+		filePart{contentType: "image/png", content: png},
+		filePart{contentType: "image/png", content: bytes.Repeat(png, 100)},
+	)
+
+	tests := []struct {
+		name         string
+		maxBytes     int64
+		expectedCode int
+	}{
+		{
+			name:         "body within limit should return 200",
+			maxBytes:     int64(len(body)),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "body over limit should return 413",
+			maxBytes:     int64(len(body)) - 1,
+			expectedCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var read []byte
+			wrapped := contenttype.Sniff(
+				[]string{"image/*"},
+				contenttype.WithMaxMultipartSize(test.maxBytes),
+			)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var err error
+				read, err = io.ReadAll(r.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+			req.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			wrapped.ServeHTTP(w, req)
+
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+			if test.expectedCode == http.StatusOK && !bytes.Equal(read, body) {
+				t.Errorf("expected next handler to read the full body")
+			}
+		})
+	}
+}
@@ -29,7 +29,7 @@ func New(logger *slog.Logger) func(http.Handler) http.Handler {
 				return
 			}
 
-			ctx := AppendCtx(r.Context(), slog.String("request_id", requestID))
+			ctx := AppendCtx(r.Context(), slog.String(requestIDKey, requestID))
 			r = r.WithContext(ctx)
 
 			next.ServeHTTP(wrapped, r)
@@ -45,7 +45,7 @@ func New(logger *slog.Logger) func(http.Handler) http.Handler {
 				"Request",
 				"method", r.Method,
 				"path", r.URL.Path,
-				"params", r.URL.Query(),
+				params(r, wrapped.Status),
 				"status", wrapped.Status,
 				"duration", time.Since(start),
 				"content_length", wrapped.BytesWritten,
@@ -54,7 +54,24 @@ func New(logger *slog.Logger) func(http.Handler) http.Handler {
 	}
 }
 
-const requestIDLength = 32
+const (
+	requestIDLength = 32
+	requestIDKey    = "request_id"
+	maxParsedQuery  = 2048
+)
+
+// params parses the query unless it was rejected or is too long to be worth
+// parsing, in which case the raw query is logged truncated.
+func params(r *http.Request, status int) slog.Attr {
+	query := r.URL.RawQuery
+	if status != http.StatusRequestURITooLong && len(query) <= maxParsedQuery {
+		return slog.Any("params", r.URL.Query())
+	}
+	if len(query) > maxParsedQuery {
+		query = query[:maxParsedQuery]
+	}
+	return slog.String("query", query)
+}
 
 func requestID() (string, error) {
 	b := make([]byte, requestIDLength)
@@ -65,6 +82,19 @@ func requestID() (string, error) {
 	return base64.RawURLEncoding.EncodeToString(b), nil
 }
 
+func RequestID(ctx context.Context) (string, bool) {
+	attrs, ok := ctx.Value(slogFields).([]slog.Attr)
+	if !ok {
+		return "", false
+	}
+	for _, attr := range attrs {
+		if attr.Key == requestIDKey {
+			return attr.Value.String(), true
+		}
+	}
+	return "", false
+}
+
 type contextKey string
 
 const slogFields contextKey = "slog_fields"
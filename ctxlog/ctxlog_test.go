@@ -10,11 +10,13 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/fivethirty/middest/ctxlog"
 	"github.com/fivethirty/middest/internal/testhandler"
+	"github.com/fivethirty/middest/requestsize"
 )
 
 type logEntry struct {
@@ -24,6 +26,7 @@ type logEntry struct {
 	Method        string        `json:"method"`
 	Path          string        `json:"path"`
 	Params        url.Values    `json:"params"`
+	Query         string        `json:"query"`
 	RequestID     string        `json:"request_id"`
 	Duration      time.Duration `json:"duration"`
 	ContentLength int           `json:"content_length"`
@@ -184,3 +187,92 @@ func TestLogsWithContext(t *testing.T) {
 		})
 	}
 }
+
+func TestRequestID(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := ctxlog.RequestID(context.Background()); ok {
+		t.Error("expected no request ID without ctxlog")
+	}
+
+	var requestID string
+	buffer := bytes.NewBuffer(nil)
+	handler := ctxlog.New(ctxlog.NewLogger(buffer))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID, _ = ctxlog.RequestID(r.Context())
+		}),
+	)
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	entries := logs(buffer, t)
+	if requestID == "" {
+		t.Fatal("expected request ID to be set")
+	}
+	if len(entries) != 1 || entries[0].RequestID != requestID {
+		t.Errorf("expected request ID %s to match the logged one", requestID)
+	}
+}
+
+func TestLogRejectedQuery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedParams int
+		expectedQuery  string
+	}{
+		{
+			name:           "accepted query should be logged parsed",
+			query:          "a=1&b=2",
+			expectedStatus: http.StatusOK,
+			expectedParams: 2,
+		},
+		{
+			name:           "rejected query should be logged raw",
+			query:          "a=1&b=2&c=3&d=4",
+			expectedStatus: http.StatusRequestURITooLong,
+			expectedQuery:  "a=1&b=2&c=3&d=4",
+		},
+		{
+			name:           "rejected long query should be logged truncated",
+			query:          "a=" + strings.Repeat("a", 5000),
+			expectedStatus: http.StatusRequestURITooLong,
+			expectedQuery:  ("a=" + strings.Repeat("a", 5000))[:2048],
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := bytes.NewBuffer(nil)
+			handler := ctxlog.New(ctxlog.NewLogger(buffer))(
+				requestsize.Request(requestsize.RequestLimits{
+					MaxQueryParams: 3,
+					MaxQueryBytes:  4096,
+				})(
+					testhandler.New(t, http.StatusOK, 0),
+				),
+			)
+			req := httptest.NewRequest(http.MethodGet, "/?"+test.query, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			entries := logs(buffer, t)
+			if len(entries) != 1 {
+				t.Fatalf("expected 1 log entry, got %d", len(entries))
+			}
+			if len(entries[0].Params) != test.expectedParams {
+				t.Errorf("expected %d params, got %d", test.expectedParams, len(entries[0].Params))
+			}
+			if entries[0].Query != test.expectedQuery {
+				t.Errorf("expected query %q, got %q", test.expectedQuery, entries[0].Query)
+			}
+		})
+	}
+}
@@ -1,17 +1,24 @@
 package errs
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fivethirty/middest/handlers"
 	"github.com/fivethirty/middest/internal/response"
+	"github.com/fivethirty/middest/requestsize"
 )
 
 type StatusError struct {
 	error
 	Status          int
 	ResponseMessage string
+	Header          http.Header
 }
 
 func NewStatusError(
@@ -26,23 +33,97 @@ func NewStatusError(
 	}
 }
 
+func NewTooManyRequestsError(
+	err error,
+	retryAfter time.Duration,
+	responseMessage string,
+) StatusError {
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	return NewStatusError(err, http.StatusTooManyRequests, responseMessage).
+		WithHeader("Retry-After", strconv.FormatInt(max(seconds, 0), 10))
+}
+
+func NewUnauthorizedError(
+	err error,
+	challenge string,
+	responseMessage string,
+) StatusError {
+	return NewStatusError(err, http.StatusUnauthorized, responseMessage).
+		WithHeader("WWW-Authenticate", challenge)
+}
+
+func NewMethodNotAllowedError(
+	err error,
+	allowedMethods []string,
+	responseMessage string,
+) StatusError {
+	return NewStatusError(err, http.StatusMethodNotAllowed, responseMessage).
+		WithHeader("Allow", strings.Join(allowedMethods, ", "))
+}
+
+func (se StatusError) WithHeader(key, value string) StatusError {
+	header := se.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Add(key, value)
+	se.Header = header
+	return se
+}
+
 type HttpHandlerWithError func(http.ResponseWriter, *http.Request) error
 
-type ErrorWriter func(w http.ResponseWriter, code int, responseMessage string)
+type ErrorWriter func(
+	w http.ResponseWriter,
+	r *http.Request,
+	code int,
+	responseMessage string,
+)
 
 type errs struct {
-	writeError ErrorWriter
-	logger     *slog.Logger
+	writeError    ErrorWriter
+	logger        *slog.Logger
+	reporter      Reporter
+	bufferSize    int
+	recoverPanics bool
+}
+
+type Option func(*errs)
+
+func WithReporter(reporter Reporter) Option {
+	return func(e *errs) {
+		e.reporter = reporter
+	}
+}
+
+// WithBuffering holds up to maxBytes of handler output until the handler
+// returns so that a late error can still replace it with a clean error page.
+// An error after more output than that aborts the response instead.
+func WithBuffering(maxBytes int) Option {
+	return func(e *errs) {
+		e.bufferSize = maxBytes
+	}
+}
+
+func WithPanicRecovery() Option {
+	return func(e *errs) {
+		e.recoverPanics = true
+	}
 }
 
 func New(
 	writeError ErrorWriter,
 	logger *slog.Logger,
+	opts ...Option,
 ) *errs {
-	return &errs{
+	e := &errs{
 		writeError: writeError,
 		logger:     logger,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 func (e *errs) WriteMissingErrorBody(next http.Handler) http.Handler {
@@ -52,40 +133,193 @@ func (e *errs) WriteMissingErrorBody(next http.Handler) http.Handler {
 			wrapped = response.Wrap(w)
 		}
 		defer func() {
-			if wrapped.IsHeaderWritten {
+			recovered := recover()
+			if recovered == nil && wrapped.IsHeaderWritten {
 				return
 			}
-			e.writeError(wrapped, http.StatusInternalServerError, "")
+
+			r := withErrorRef(r)
+			if recovered != nil && recovered != http.ErrAbortHandler {
+				err := NewPanicError(recovered, debug.Stack())
+				e.report(r, err, http.StatusInternalServerError)
+			}
+			if !wrapped.IsHeaderWritten {
+				e.writeMissingError(wrapped, r, http.StatusInternalServerError)
+			}
+			if recovered != nil {
+				panic(recovered)
+			}
 		}()
 
 		next.ServeHTTP(wrapped, r)
 
 		if wrapped.IsHeaderWritten && wrapped.Status >= 400 && wrapped.BytesWritten == 0 {
-			e.writeError(wrapped, wrapped.Status, "")
+			e.writeMissingError(wrapped, r, wrapped.Status)
 		}
 	})
 }
 
+func (e *errs) writeMissingError(w http.ResponseWriter, r *http.Request, status int) {
+	r = withErrorRef(r)
+
+	level := slog.LevelInfo
+	if status >= 500 {
+		level = slog.LevelError
+	}
+	e.logger.Log(
+		r.Context(),
+		level,
+		"Error Response",
+		"status", status,
+		"error_ref", ErrorRef(r.Context()),
+	)
+
+	e.writeError(w, r, status, "")
+}
+
 func (e *errs) ToHandlerFunc(he HttpHandlerWithError) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := he(w, r)
+		if e.recoverPanics {
+			wrapped, ok := w.(*response.ResponseWriter)
+			if !ok {
+				wrapped = response.Wrap(w)
+			}
+			w = wrapped
+			defer e.recoverPanic(wrapped, r)
+		}
+
+		if e.bufferSize <= 0 {
+			if err := he(w, r); err != nil {
+				e.handleError(w, r, err)
+			}
+			return
+		}
+
+		buffered := response.Buffer(w, e.bufferSize)
+		err := he(buffered, r)
 		if err == nil {
+			_ = buffered.Commit()
 			return
 		}
+		if buffered.IsCommitted {
+			e.abort(r, err)
+		}
+		buffered.Discard()
+		e.handleError(w, r, err)
+	}
+}
+
+func (e *errs) recoverPanic(w *response.ResponseWriter, r *http.Request) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	if recovered == http.ErrAbortHandler {
+		panic(recovered)
+	}
 
-		e.logger.ErrorContext(
-			r.Context(),
-			"Handler Error",
-			"message", err,
-		)
+	err := NewPanicError(recovered, debug.Stack())
+	if !w.IsHeaderWritten {
+		e.handleError(w, r, err)
+		return
+	}
 
-		statusErr, ok := err.(StatusError)
-		if !ok {
-			e.writeError(w, http.StatusInternalServerError, "")
-		} else {
-			e.writeError(w, statusErr.Status, statusErr.ResponseMessage)
-		}
+	e.abort(r, err)
+}
+
+// abort is for errors after part of the response has gone out. The response
+// can't be repaired, so have net/http abort it rather than let it look
+// complete.
+func (e *errs) abort(r *http.Request, err error) {
+	r = withErrorRef(r)
+	e.logError(r, err)
+	e.report(r, err, http.StatusInternalServerError)
+	panic(http.ErrAbortHandler)
+}
+
+func (e *errs) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	var redirect Redirect
+	if errors.As(err, &redirect) {
+		redirect.write(w, r)
+		return
+	}
+
+	r = withErrorRef(r)
+	e.logError(r, err)
+
+	var statusErr StatusError
+	ok := errors.As(err, &statusErr)
+	if !ok {
+		statusErr, ok = limitStatusError(err)
+	}
+	if !ok {
+		e.report(r, err, http.StatusInternalServerError)
+		e.writeError(w, r, http.StatusInternalServerError, "")
+		return
+	}
+
+	if statusErr.Status >= 500 {
+		e.report(r, err, statusErr.Status)
+	}
+	for key, values := range statusErr.Header {
+		w.Header()[http.CanonicalHeaderKey(key)] = values
+	}
+	e.writeError(w, r, statusErr.Status, statusErr.ResponseMessage)
+}
+
+func limitStatusError(err error) (StatusError, bool) {
+	var timeoutErr *requestsize.TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return NewStatusError(err, http.StatusRequestTimeout, "request timeout"), true
+	}
+	var limitErr *requestsize.LimitError
+	if !errors.As(err, &limitErr) {
+		return StatusError{}, false
 	}
+	var responseMessage string
+	switch limitErr.Status {
+	case http.StatusRequestEntityTooLarge:
+		responseMessage = "request body too large"
+	case http.StatusRequestURITooLong:
+		responseMessage = "request url too long"
+	case http.StatusRequestHeaderFieldsTooLarge:
+		responseMessage = "request headers too large"
+	default:
+		responseMessage = "invalid request body"
+	}
+	return NewStatusError(err, limitErr.Status, responseMessage), true
+}
+
+func (e *errs) logError(r *http.Request, err error) {
+	e.logger.ErrorContext(
+		r.Context(),
+		"Handler Error",
+		"message", err,
+		"error_ref", ErrorRef(r.Context()),
+	)
+}
+
+func (e *errs) report(r *http.Request, err error, status int) {
+	if e.reporter == nil {
+		return
+	}
+
+	var stack []byte
+	var st stacker
+	if errors.As(err, &st) {
+		stack = st.Stack()
+	}
+
+	e.reporter.Report(r.Context(), Report{
+		Err:         err,
+		Status:      status,
+		Method:      r.Method,
+		URL:         r.URL.String(),
+		Stack:       stack,
+		Fingerprint: Fingerprint(err, stack),
+		Ref:         ErrorRef(r.Context()),
+		Time:        time.Now(),
+	})
 }
 
 func (e *errs) WithMiddleware(
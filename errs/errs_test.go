@@ -2,16 +2,20 @@ package errs_test
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/fivethirty/middest/errs"
 	"github.com/fivethirty/middest/internal/testhandler"
+	"github.com/fivethirty/middest/requestsize"
 )
 
-func writeError(w http.ResponseWriter, code int, responseMessage string) {
+func writeError(w http.ResponseWriter, _ *http.Request, code int, responseMessage string) {
 	w.WriteHeader(code)
 	_, _ = w.Write([]byte(responseMessage))
 }
@@ -122,6 +126,24 @@ func TestToHandlerFunc(t *testing.T) {
 			),
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "should return limit status when error wraps LimitError",
+			err: fmt.Errorf("parse form: %w", &requestsize.LimitError{
+				Limit:  "file size",
+				Max:    10,
+				Status: http.StatusRequestEntityTooLarge,
+			}),
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name: "should return 408 when error wraps TimeoutError",
+			err: fmt.Errorf("read body: %w", &requestsize.TimeoutError{
+				Limit:     "upload rate",
+				BytesRead: 10,
+				Elapsed:   time.Second,
+			}),
+			expectedStatus: http.StatusRequestTimeout,
+		},
 		{
 			name:           "should do nothing when error is nil",
 			err:            nil,
@@ -161,3 +183,174 @@ func TestToHandlerFunc(t *testing.T) {
 		})
 	}
 }
+
+func TestToHandlerFuncHeaders(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedHeader string
+		expectedValue  string
+	}{
+		{
+			name: "too many requests should set Retry-After",
+			err: errs.NewTooManyRequestsError(
+				errors.New("rate limited"),
+				1500*time.Millisecond,
+				"slow down",
+			),
+			expectedStatus: http.StatusTooManyRequests,
+			expectedHeader: "Retry-After",
+			expectedValue:  "2",
+		},
+		{
+			name: "unauthorized should set WWW-Authenticate",
+			err: errs.NewUnauthorizedError(
+				errors.New("no session"),
+				`Bearer realm="middest"`,
+				"log in",
+			),
+			expectedStatus: http.StatusUnauthorized,
+			expectedHeader: "WWW-Authenticate",
+			expectedValue:  `Bearer realm="middest"`,
+		},
+		{
+			name: "method not allowed should set Allow",
+			err: errs.NewMethodNotAllowedError(
+				errors.New("wrong method"),
+				[]string{http.MethodGet, http.MethodPost},
+				"",
+			),
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedHeader: "Allow",
+			expectedValue:  "GET, POST",
+		},
+		{
+			name: "custom header should be set",
+			err: errs.NewStatusError(
+				errors.New("teapot"),
+				http.StatusTeapot,
+				"",
+			).WithHeader("x-custom", "value"),
+			expectedStatus: http.StatusTeapot,
+			expectedHeader: "X-Custom",
+			expectedValue:  "value",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := errs.New(writeError, slog.Default())
+			handler := e.ToHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return test.err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get(test.expectedHeader); got != test.expectedValue {
+				t.Errorf(
+					"expected header %s to be %q, got %q",
+					test.expectedHeader,
+					test.expectedValue,
+					got,
+				)
+			}
+		})
+	}
+}
+
+func TestToHandlerFuncBuffering(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		bufferSize     int
+		body           string
+		err            error
+		expectedStatus int
+		expectedBody   string
+		expectedAbort  bool
+	}{
+		{
+			name:           "success should flush buffered output",
+			bufferSize:     1024,
+			body:           "<html>page</html>",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "<html>page</html>",
+		},
+		{
+			name:       "late error should discard buffered output",
+			bufferSize: 1024,
+			body:       "<html>half",
+			err: errs.NewStatusError(
+				errors.New("template failed"),
+				http.StatusBadGateway,
+				"error page",
+			),
+			expectedStatus: http.StatusBadGateway,
+			expectedBody:   "error page",
+		},
+		{
+			name:           "error after output over buffer size should abort",
+			bufferSize:     4,
+			body:           "<html>half",
+			err:            errors.New("template failed"),
+			expectedStatus: http.StatusOK,
+			expectedBody:   "<html>half",
+			expectedAbort:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			writeStatusText := func(w http.ResponseWriter, _ *http.Request, code int, msg string) {
+				if msg == "" {
+					msg = http.StatusText(code)
+				}
+				w.WriteHeader(code)
+				_, _ = w.Write([]byte(msg))
+			}
+			e := errs.New(
+				writeStatusText,
+				slog.New(slog.NewTextHandler(io.Discard, nil)),
+				errs.WithBuffering(test.bufferSize),
+			)
+			handler := e.ToHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				w.Header().Set("Content-Type", "text/html")
+				_, _ = w.Write([]byte(test.body))
+				return test.err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+			func() {
+				defer func() {
+					recovered := recover()
+					if aborted := recovered == http.ErrAbortHandler; aborted != test.expectedAbort {
+						t.Errorf("expected abort %v, got %v", test.expectedAbort, recovered)
+					}
+				}()
+				handler(w, req)
+			}()
+
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			if w.Body.String() != test.expectedBody {
+				t.Errorf("expected body %q, got %q", test.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
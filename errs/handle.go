@@ -0,0 +1,237 @@
+package errs
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const maxMultipartMemory = 32 << 20
+
+type Validator interface {
+	Validate() error
+}
+
+type StatusCoder interface {
+	StatusCode() int
+}
+
+func Handle[Req, Resp any](fn func(context.Context, Req) (Resp, error)) HttpHandlerWithError {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var req Req
+		err := decode(r, &req)
+		if r.MultipartForm != nil {
+			defer func() {
+				_ = r.MultipartForm.RemoveAll()
+			}()
+		}
+		if err != nil {
+			return err
+		}
+
+		if validator, ok := any(&req).(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				var statusErr StatusError
+				if errors.As(err, &statusErr) {
+					return err
+				}
+				return NewStatusError(err, http.StatusBadRequest, err.Error())
+			}
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			return err
+		}
+
+		var buffer bytes.Buffer
+		if err := json.NewEncoder(&buffer).Encode(resp); err != nil {
+			return fmt.Errorf("encoding response: %w", err)
+		}
+
+		status := http.StatusOK
+		if statusCoder, ok := any(resp).(StatusCoder); ok {
+			status = statusCoder.StatusCode()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, err = w.Write(buffer.Bytes())
+		return err
+	}
+}
+
+func decode(r *http.Request, v any) error {
+	mediaType := ""
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		var err error
+		mediaType, _, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return NewStatusError(err, http.StatusBadRequest, "invalid content type")
+		}
+	}
+
+	var err error
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		err = decodeJSON(r, v)
+	case mediaType == "multipart/form-data":
+		err = r.ParseMultipartForm(maxMultipartMemory)
+		if err == nil {
+			err = decodeForm(r.Form, v)
+		}
+	case mediaType == "application/x-www-form-urlencoded",
+		mediaType == "" && r.ContentLength == 0:
+		err = r.ParseForm()
+		if err == nil {
+			err = decodeForm(r.Form, v)
+		}
+	default:
+		return NewStatusError(
+			fmt.Errorf("unsupported content type %q", mediaType),
+			http.StatusUnsupportedMediaType,
+			"unsupported content type",
+		)
+	}
+
+	if err == nil || errors.Is(err, errFormTarget) {
+		return err
+	}
+	if statusErr, ok := limitStatusError(err); ok {
+		return statusErr
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return NewStatusError(err, http.StatusRequestEntityTooLarge, "request body too large")
+	}
+	return NewStatusError(err, http.StatusBadRequest, "invalid request body")
+}
+
+func decodeJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("request body must contain a single JSON value")
+	}
+	return nil
+}
+
+// errFormTarget is a programming error rather than a bad request, so decode
+// passes it through to be answered with a 500.
+var errFormTarget = errors.New("cannot decode form into")
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+func decodeForm(values url.Values, v any) error {
+	target := reflect.ValueOf(v).Elem()
+	for target.Kind() == reflect.Pointer {
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+	if target.Kind() != reflect.Struct {
+		return fmt.Errorf("%w %s", errFormTarget, target.Type())
+	}
+
+	for i := range target.NumField() {
+		field := target.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("form"); ok {
+			name, _, _ = strings.Cut(tag, ",")
+		}
+		if name == "-" {
+			continue
+		}
+
+		formValues, ok := values[name]
+		if !ok || len(formValues) == 0 {
+			continue
+		}
+		if err := setField(target.Field(i), formValues); err != nil {
+			return fmt.Errorf("field %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, values []string) error {
+	isTextUnmarshaler := reflect.PointerTo(field.Type()).Implements(textUnmarshalerType)
+	if field.Kind() == reflect.Slice && !isTextUnmarshaler {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setValue(field, values[0])
+}
+
+func setValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		return setValue(field.Elem(), value)
+	}
+
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		unmarshaler, _ := field.Addr().Interface().(encoding.TextUnmarshaler)
+		return unmarshaler.UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		if value == "on" {
+			field.SetBool(true)
+			return nil
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("%w %s", errFormTarget, field.Type())
+	}
+	return nil
+}
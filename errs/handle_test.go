@@ -0,0 +1,345 @@
+package errs_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/middest/errs"
+	"github.com/fivethirty/middest/requestsize"
+)
+
+type createItem struct {
+	Name  string   `json:"name"  form:"name"`
+	Count int      `json:"count" form:"count"`
+	Tags  []string `json:"tags"  form:"tag"`
+	Draft bool     `json:"draft" form:"draft"`
+}
+
+func (ci createItem) Validate() error {
+	if ci.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type itemCreated struct {
+	Name  string   `json:"name"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+	Draft bool     `json:"draft"`
+}
+
+func (itemCreated) StatusCode() int {
+	return http.StatusCreated
+}
+
+func TestHandle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		contentType    string
+		body           string
+		maxBytes       int64
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "JSON body should be decoded",
+			method:         http.MethodPost,
+			contentType:    "application/json; charset=utf-8",
+			body:           `{"name":"a","count":2,"tags":["x","y"],"draft":true}`,
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"name":"a","count":2,"tags":["x","y"],"draft":true}`,
+		},
+		{
+			name:           "form body should be decoded",
+			method:         http.MethodPost,
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "name=a&count=2&tag=x&tag=y&draft=on",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"name":"a","count":2,"tags":["x","y"],"draft":true}`,
+		},
+		{
+			name:           "query should be decoded without body",
+			method:         http.MethodGet,
+			path:           "/?name=a&count=2",
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"name":"a","count":2,"tags":null,"draft":false}`,
+		},
+		{
+			name:           "invalid JSON should return 400",
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           `{"name":`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid request body",
+		},
+		{
+			name:           "trailing JSON should return 400",
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           `{"name":"a"}{"name":"b"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid request body",
+		},
+		{
+			name:           "invalid form value should return 400",
+			method:         http.MethodPost,
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "name=a&count=many",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "invalid request body",
+		},
+		{
+			name:           "failed validation should return 400",
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           `{"count":2}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "name is required",
+		},
+		{
+			name:           "unsupported content type should return 415",
+			method:         http.MethodPost,
+			contentType:    "text/csv",
+			body:           "name,count",
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   "unsupported content type",
+		},
+		{
+			name:           "body over requestsize limit should return 413",
+			method:         http.MethodPost,
+			contentType:    "application/json",
+			body:           `{"name":"` + strings.Repeat("a", 100) + `"}`,
+			maxBytes:       10,
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "request body too large",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := errs.New(writeError, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			var handler http.Handler = e.ToHandlerFunc(errs.Handle(
+				func(_ context.Context, req createItem) (itemCreated, error) {
+					return itemCreated(req), nil
+				},
+			))
+			if test.maxBytes > 0 {
+				handler = requestsize.New(test.maxBytes)(handler)
+			}
+
+			path := test.path
+			if path == "" {
+				path = "/"
+			}
+			req := httptest.NewRequest(test.method, path, strings.NewReader(test.body))
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != test.expectedBody {
+				t.Errorf("expected body %q, got %q", test.expectedBody, got)
+			}
+			if test.expectedStatus < 400 && w.Header().Get("Content-Type") != "application/json" {
+				t.Errorf("expected JSON content type, got %q", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestHandleError(t *testing.T) {
+	t.Parallel()
+
+	e := errs.New(writeError, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := e.ToHandlerFunc(errs.Handle(
+		func(_ context.Context, req createItem) (itemCreated, error) {
+			return itemCreated{}, errs.NewStatusError(errors.New("exists"), http.StatusConflict, "")
+		},
+	))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"a"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+type reservedItem struct {
+	Name string `json:"name"`
+}
+
+func (ri reservedItem) Validate() error {
+	if ri.Name == "admin" {
+		return fmt.Errorf(
+			"validating item: %w",
+			errs.NewStatusError(errors.New("reserved name"), http.StatusConflict, "name taken"),
+		)
+	}
+	return nil
+}
+
+func TestHandleWrappedValidationError(t *testing.T) {
+	t.Parallel()
+
+	e := errs.New(writeError, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := e.ToHandlerFunc(errs.Handle(
+		func(_ context.Context, req reservedItem) (itemCreated, error) {
+			return itemCreated{Name: req.Name}, nil
+		},
+	))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"admin"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	if w.Body.String() != "name taken" {
+		t.Errorf("expected body %q, got %q", "name taken", w.Body.String())
+	}
+}
+
+func TestHandleFormIntoNonStruct(t *testing.T) {
+	t.Parallel()
+
+	e := errs.New(writeError, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := e.ToHandlerFunc(errs.Handle(
+		func(_ context.Context, req string) (string, error) {
+			return req, nil
+		},
+	))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name=a"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+type taggedItem struct {
+	Labels map[string]string `form:"labels"`
+}
+
+func TestHandleFormUnsupportedField(t *testing.T) {
+	t.Parallel()
+
+	e := errs.New(writeError, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := e.ToHandlerFunc(errs.Handle(
+		func(_ context.Context, req taggedItem) (taggedItem, error) {
+			return req, nil
+		},
+	))
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/?labels=a", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+}
+
+func TestHandleMultipartRemovesFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if err := writer.WriteField("name", "a"); err != nil {
+		t.Fatal(err)
+	}
+	part, err := writer.CreateFormFile("file", "large.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write(make([]byte, 33<<20)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	spilled := 0
+	e := errs.New(writeError, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := e.ToHandlerFunc(errs.Handle(
+		func(_ context.Context, req createItem) (itemCreated, error) {
+			entries, _ := os.ReadDir(dir)
+			spilled = len(entries)
+			return itemCreated(req), nil
+		},
+	))
+
+	req := httptest.NewRequest(http.MethodPost, "/", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	handler(httptest.NewRecorder(), req.WithContext(context.Background()))
+
+	if spilled == 0 {
+		t.Fatal("expected the upload to spill to a temp file")
+	}
+	if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+		t.Errorf("expected temp files to be removed, got %v %v", entries, err)
+	}
+}
+
+type stalledBody struct{}
+
+func (stalledBody) Read(p []byte) (int, error) {
+	time.Sleep(20 * time.Millisecond)
+	p[0] = ' '
+	return 1, nil
+}
+
+func TestHandleRate(t *testing.T) {
+	t.Parallel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	e := errs.New(writeError, logger)
+	handler := requestsize.Rate(logger, requestsize.RateLimits{ReadTimeout: 50 * time.Millisecond})(
+		e.ToHandlerFunc(errs.Handle(
+			func(_ context.Context, req createItem) (itemCreated, error) {
+				return itemCreated(req), nil
+			},
+		)),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/", stalledBody{})
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestTimeout {
+		t.Errorf("expected status %d, got %d", http.StatusRequestTimeout, w.Code)
+	}
+	if w.Body.String() != "request timeout" {
+		t.Errorf("expected body %q, got %q", "request timeout", w.Body.String())
+	}
+}
@@ -0,0 +1,131 @@
+package errs
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+type ReportGroup struct {
+	Fingerprint string
+	Count       int
+	FirstSeen   time.Time
+	LastSeen    time.Time
+	Latest      Report
+}
+
+type MemoryReporter struct {
+	maxGroups int
+	mu        sync.Mutex
+	groups    map[string]*ReportGroup
+}
+
+func NewMemoryReporter(maxGroups int) *MemoryReporter {
+	return &MemoryReporter{
+		maxGroups: maxGroups,
+		groups:    map[string]*ReportGroup{},
+	}
+}
+
+func (mr *MemoryReporter) Report(_ context.Context, report Report) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	group, ok := mr.groups[report.Fingerprint]
+	if !ok {
+		if mr.maxGroups > 0 && len(mr.groups) >= mr.maxGroups {
+			mr.evictOldest()
+		}
+		group = &ReportGroup{
+			Fingerprint: report.Fingerprint,
+			FirstSeen:   report.Time,
+		}
+		mr.groups[report.Fingerprint] = group
+	}
+	group.Count += 1 + report.Suppressed
+	group.LastSeen = report.Time
+	group.Latest = report
+}
+
+func (mr *MemoryReporter) evictOldest() {
+	var oldest *ReportGroup
+	for _, group := range mr.groups {
+		if oldest == nil || group.LastSeen.Before(oldest.LastSeen) {
+			oldest = group
+		}
+	}
+	if oldest != nil {
+		delete(mr.groups, oldest.Fingerprint)
+	}
+}
+
+func (mr *MemoryReporter) Groups() []ReportGroup {
+	mr.mu.Lock()
+	groups := make([]ReportGroup, 0, len(mr.groups))
+	for _, group := range mr.groups {
+		groups = append(groups, *group)
+	}
+	mr.mu.Unlock()
+
+	slices.SortFunc(groups, func(a, b ReportGroup) int {
+		return b.LastSeen.Compare(a.LastSeen)
+	})
+	return groups
+}
+
+var debugPage = template.Must(template.New("errors").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Recent Errors</title>
+<style>
+body { font-family: sans-serif; margin: 2rem; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ccc; padding: 0.5rem; text-align: left; vertical-align: top; }
+pre { margin: 0; white-space: pre-wrap; font-size: 0.8rem; }
+</style>
+</head>
+<body>
+<h1>Recent Errors</h1>
+{{if .}}
+<table>
+<tr>
+<th>Fingerprint</th><th>Count</th><th>Last Seen</th><th>First Seen</th><th>Latest</th>
+</tr>
+{{range .}}
+<tr>
+<td><code>{{.Fingerprint}}</code></td>
+<td>{{.Count}}</td>
+<td>{{.LastSeen.Format "2006-01-02 15:04:05"}}</td>
+<td>{{.FirstSeen.Format "2006-01-02 15:04:05"}}</td>
+<td>
+<p>{{.Latest.Status}} {{.Latest.Method}} {{.Latest.URL}}</p>
+<p>{{.Latest.Err}}</p>
+{{if .Latest.Stack}}
+<details><summary>Stack</summary><pre>{{printf "%s" .Latest.Stack}}</pre></details>
+{{end}}
+</td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p>No errors reported.</p>
+{{end}}
+</body>
+</html>
+`))
+
+func (mr *MemoryReporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	var buffer bytes.Buffer
+	if err := debugPage.Execute(&buffer, mr.Groups()); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buffer.Bytes())
+}
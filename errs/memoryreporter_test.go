@@ -0,0 +1,63 @@
+package errs_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/middest/errs"
+)
+
+func TestMemoryReporter(t *testing.T) {
+	t.Parallel()
+
+	reporter := errs.NewMemoryReporter(2)
+	start := time.Now()
+
+	reports := []errs.Report{
+		{Fingerprint: "a", Err: errors.New("a"), Time: start},
+		{Fingerprint: "a", Err: errors.New("a"), Time: start.Add(time.Second), Suppressed: 3},
+		{Fingerprint: "b", Err: errors.New("b"), Time: start.Add(2 * time.Second)},
+		{Fingerprint: "c", Err: errors.New("<c>"), Time: start.Add(3 * time.Second)},
+	}
+	for _, report := range reports {
+		reporter.Report(context.Background(), report)
+	}
+
+	groups := reporter.Groups()
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %d", len(groups))
+	}
+	if groups[0].Fingerprint != "c" || groups[1].Fingerprint != "b" {
+		t.Errorf("expected groups c, b, got %s, %s", groups[0].Fingerprint, groups[1].Fingerprint)
+	}
+
+	reporter = errs.NewMemoryReporter(10)
+	for _, report := range reports {
+		reporter.Report(context.Background(), report)
+	}
+	groups = reporter.Groups()
+	if groups[2].Fingerprint != "a" || groups[2].Count != 5 {
+		t.Errorf("expected group a to have count 5, got %d", groups[2].Count)
+	}
+
+	w := httptest.NewRecorder()
+	reporter.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/errors", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	body := w.Body.String()
+	for _, fingerprint := range []string{"a", "b", "c"} {
+		if !strings.Contains(body, "<code>"+fingerprint+"</code>") {
+			t.Errorf("expected page to list fingerprint %s", fingerprint)
+		}
+	}
+	if !strings.Contains(body, "&lt;c&gt;") {
+		t.Errorf("expected error message to be escaped")
+	}
+}
@@ -0,0 +1,14 @@
+package errs
+
+type Middleware func(HttpHandlerWithError) HttpHandlerWithError
+
+func WithErrorMiddleware(
+	middlewares []Middleware,
+	handler HttpHandlerWithError,
+) HttpHandlerWithError {
+	next := handler
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		next = middlewares[i](next)
+	}
+	return next
+}
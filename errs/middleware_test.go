@@ -0,0 +1,94 @@
+package errs_test
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fivethirty/middest/errs"
+)
+
+func TestWithErrorMiddleware(t *testing.T) {
+	t.Parallel()
+
+	requireUser := func(next errs.HttpHandlerWithError) errs.HttpHandlerWithError {
+		return func(w http.ResponseWriter, r *http.Request) error {
+			if r.Header.Get("X-User") == "" {
+				return errs.NewUnauthorizedError(errors.New("no user"), "Session", "log in")
+			}
+			return next(w, r)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		user             string
+		expectedStatus   int
+		expectedBody     string
+		expectedExecuted []int
+	}{
+		{
+			name:             "middleware should run in order",
+			user:             "someone",
+			expectedStatus:   http.StatusOK,
+			expectedBody:     "ok",
+			expectedExecuted: []int{1, 2},
+		},
+		{
+			name:             "rejection should be written by ErrorWriter",
+			expectedStatus:   http.StatusUnauthorized,
+			expectedBody:     "log in",
+			expectedExecuted: []int{1},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			executed := []int{}
+			record := func(n int) errs.Middleware {
+				return func(next errs.HttpHandlerWithError) errs.HttpHandlerWithError {
+					return func(w http.ResponseWriter, r *http.Request) error {
+						executed = append(executed, n)
+						return next(w, r)
+					}
+				}
+			}
+
+			e := errs.New(writeError, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			handler := e.ToHandlerFunc(errs.WithErrorMiddleware(
+				[]errs.Middleware{record(1), requireUser, record(2)},
+				func(w http.ResponseWriter, r *http.Request) error {
+					_, err := w.Write([]byte("ok"))
+					return err
+				},
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.user != "" {
+				req.Header.Set("X-User", test.user)
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			if w.Body.String() != test.expectedBody {
+				t.Errorf("expected body %q, got %q", test.expectedBody, w.Body.String())
+			}
+			if len(executed) != len(test.expectedExecuted) {
+				t.Fatalf("expected execution %v, got %v", test.expectedExecuted, executed)
+			}
+			for i := range executed {
+				if executed[i] != test.expectedExecuted[i] {
+					t.Errorf("expected execution %v, got %v", test.expectedExecuted, executed)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package errs
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func (e *errs) NotFoundHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.handleError(w, r, NewStatusError(
+			fmt.Errorf("no route for %s %s", r.Method, r.URL.Path),
+			http.StatusNotFound,
+			"",
+		))
+	})
+}
+
+func (e *errs) MethodNotAllowedHandler(allowedMethods ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.handleError(w, r, NewMethodNotAllowedError(
+			fmt.Errorf("method %s not allowed for %s", r.Method, r.URL.Path),
+			allowedMethods,
+			"",
+		))
+	})
+}
+
+// Mux serves requests with mux but replaces its plain text "404 page not
+// found" and "405 method not allowed" responses with the ErrorWriter.
+func (e *errs) Mux(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.RequestURI == "*" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		handler, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &headerRecorder{header: http.Header{}}
+		handler.ServeHTTP(recorder, r)
+		if recorder.status != http.StatusMethodNotAllowed {
+			e.NotFoundHandler().ServeHTTP(w, r)
+			return
+		}
+
+		allowedMethods := strings.Split(recorder.header.Get("Allow"), ", ")
+		e.MethodNotAllowedHandler(allowedMethods...).ServeHTTP(w, r)
+	})
+}
+
+type headerRecorder struct {
+	header http.Header
+	status int
+}
+
+func (hr *headerRecorder) Header() http.Header {
+	return hr.header
+}
+
+func (hr *headerRecorder) WriteHeader(code int) {
+	if hr.status == 0 {
+		hr.status = code
+	}
+}
+
+func (hr *headerRecorder) Write(body []byte) (int, error) {
+	hr.WriteHeader(http.StatusOK)
+	return len(body), nil
+}
+
+// AcceptErrorWriter uses jsonWriter for clients that prefer JSON over HTML by
+// q-value and htmlWriter for everyone else, including ties.
+func AcceptErrorWriter(htmlWriter ErrorWriter, jsonWriter ErrorWriter) ErrorWriter {
+	return func(w http.ResponseWriter, r *http.Request, code int, responseMessage string) {
+		w.Header().Add("Vary", "Accept")
+		accept := r.Header.Values("Accept")
+		if acceptQuality(accept, "application", "json") > acceptQuality(accept, "text", "html") {
+			jsonWriter(w, r, code, responseMessage)
+			return
+		}
+		htmlWriter(w, r, code, responseMessage)
+	}
+}
+
+// acceptQuality returns the q-value of the most specific range in accept
+// matching typ/subtype, or 0 if none does.
+func acceptQuality(accept []string, typ, subtype string) float64 {
+	quality, specificity := 0.0, -1
+	for _, header := range accept {
+		for part := range strings.SplitSeq(header, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			rangeType, rangeSubtype, _ := strings.Cut(mediaType, "/")
+
+			rangeSpecificity := 2
+			switch {
+			case rangeType == "*" && rangeSubtype == "*":
+				rangeSpecificity = 0
+			case rangeType == typ && rangeSubtype == "*":
+				rangeSpecificity = 1
+			case rangeType != typ || rangeSubtype != subtype:
+				continue
+			}
+			if rangeSpecificity <= specificity {
+				continue
+			}
+
+			rangeQuality := 1.0
+			if q, ok := params["q"]; ok {
+				rangeQuality, err = strconv.ParseFloat(q, 64)
+				if err != nil || rangeQuality < 0 || rangeQuality > 1 {
+					continue
+				}
+			}
+			quality, specificity = rangeQuality, rangeSpecificity
+		}
+	}
+	return quality
+}
+
+type jsonError struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Ref     string `json:"ref,omitempty"`
+}
+
+func WriteJSON(w http.ResponseWriter, r *http.Request, code int, responseMessage string) {
+	message := responseMessage
+	if message == "" {
+		message = http.StatusText(code)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(jsonError{
+		Status:  code,
+		Message: message,
+		Ref:     ErrorRef(r.Context()),
+	})
+}
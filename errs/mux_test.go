@@ -0,0 +1,181 @@
+package errs_test
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fivethirty/middest/errs"
+)
+
+func TestMux(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+		expectedAllow  string
+		servedByMux    bool
+	}{
+		{
+			name:           "matched route should be served",
+			method:         http.MethodGet,
+			path:           "/items/1",
+			expectedStatus: http.StatusOK,
+			expectedBody:   "item 1",
+		},
+		{
+			name:           "unmatched route should use ErrorWriter",
+			method:         http.MethodGet,
+			path:           "/missing",
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   "not found",
+		},
+		{
+			name:           "wrong method should use ErrorWriter with Allow",
+			method:         http.MethodDelete,
+			path:           "/items/1",
+			expectedStatus: http.StatusMethodNotAllowed,
+			expectedBody:   "not allowed",
+			expectedAllow:  "GET, HEAD, POST",
+		},
+		{
+			name:        "redirects should be served by the mux",
+			method:      http.MethodGet,
+			path:        "/folder",
+			servedByMux: true,
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("item " + r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /folder/", func(w http.ResponseWriter, r *http.Request) {})
+
+	writeStatusError := func(w http.ResponseWriter, _ *http.Request, code int, _ string) {
+		w.WriteHeader(code)
+		switch code {
+		case http.StatusNotFound:
+			_, _ = w.Write([]byte("not found"))
+		case http.StatusMethodNotAllowed:
+			_, _ = w.Write([]byte("not allowed"))
+		}
+	}
+	e := errs.New(writeStatusError, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	handler := e.Mux(mux)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(test.method, test.path, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if test.servedByMux {
+				expected := httptest.NewRecorder()
+				mux.ServeHTTP(expected, req)
+				test.expectedStatus = expected.Code
+				test.expectedBody = expected.Body.String()
+			}
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			if w.Body.String() != test.expectedBody {
+				t.Errorf("expected body %q, got %q", test.expectedBody, w.Body.String())
+			}
+			if got := w.Header().Get("Allow"); got != test.expectedAllow {
+				t.Errorf("expected Allow %q, got %q", test.expectedAllow, got)
+			}
+		})
+	}
+}
+
+func TestAcceptErrorWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                string
+		accept              string
+		expectedContentType string
+	}{
+		{
+			name:                "browser should get HTML writer",
+			accept:              "text/html,application/xhtml+xml,*/*;q=0.8",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:                "JSON client should get JSON writer",
+			accept:              "application/json",
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "JSON with q=0 should get HTML writer",
+			accept:              "application/json;q=0",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:                "JSON preferred over HTML should get JSON writer",
+			accept:              "text/html;q=0.5, application/json",
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "HTML preferred over JSON should get HTML writer",
+			accept:              "application/json;q=0.5, text/html",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:                "application wildcard should get JSON writer",
+			accept:              "application/*, text/*;q=0.1",
+			expectedContentType: "application/json",
+		},
+		{
+			name:                "specific range should win over wildcard",
+			accept:              "*/*, application/json;q=0.2",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:                "no Accept should get HTML writer",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			writeError := errs.AcceptErrorWriter(errs.WritePlainText, errs.WriteJSON)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			w := httptest.NewRecorder()
+			writeError(w, req, http.StatusNotFound, "")
+
+			if got := w.Header().Get("Content-Type"); got != test.expectedContentType {
+				t.Errorf("expected content type %q, got %q", test.expectedContentType, got)
+			}
+			if w.Header().Get("Vary") != "Accept" {
+				t.Errorf("expected Vary: Accept")
+			}
+			if test.expectedContentType != "application/json" {
+				return
+			}
+			var body map[string]any
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body["message"] != "Not Found" {
+				t.Errorf("expected message %q, got %v", "Not Found", body["message"])
+			}
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package errs
+
+import "fmt"
+
+type PanicError struct {
+	Value any
+	stack []byte
+}
+
+func NewPanicError(value any, stack []byte) PanicError {
+	return PanicError{
+		Value: value,
+		stack: stack,
+	}
+}
+
+func (pe PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", pe.Value)
+}
+
+func (pe PanicError) Unwrap() error {
+	err, _ := pe.Value.(error)
+	return err
+}
+
+func (pe PanicError) Stack() []byte {
+	return pe.stack
+}
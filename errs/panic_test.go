@@ -0,0 +1,165 @@
+package errs_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fivethirty/middest/errs"
+)
+
+var errSentinel = errors.New("sentinel")
+
+func TestPanicRecovery(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		opts           []errs.Option
+		handler        errs.HttpHandlerWithError
+		expectedStatus int
+		expectedBody   string
+		expectedPanic  any
+		expectedLogs   int
+	}{
+		{
+			name: "panic before write should use ErrorWriter",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				panic("oops!")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "",
+			expectedLogs:   1,
+		},
+		{
+			name: "panic after write should be logged and aborted",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				_, _ = w.Write([]byte("partial"))
+				panic("oops!")
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "partial",
+			expectedPanic:  http.ErrAbortHandler,
+			expectedLogs:   1,
+		},
+		{
+			name: "panic after buffered write should use ErrorWriter",
+			opts: []errs.Option{errs.WithBuffering(1024)},
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				_, _ = w.Write([]byte("partial"))
+				panic("oops!")
+			},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   "",
+			expectedLogs:   1,
+		},
+		{
+			name: "abort should be re-panicked",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				panic(http.ErrAbortHandler)
+			},
+			expectedStatus: http.StatusOK,
+			expectedPanic:  http.ErrAbortHandler,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := bytes.NewBuffer(nil)
+			opts := append([]errs.Option{errs.WithPanicRecovery()}, test.opts...)
+			e := errs.New(writeError, slog.New(slog.NewJSONHandler(buffer, nil)), opts...)
+			handler := e.ToHandlerFunc(test.handler)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			w := httptest.NewRecorder()
+			var recovered any
+			func() {
+				defer func() {
+					recovered = recover()
+				}()
+				handler(w, req)
+			}()
+
+			if recovered != test.expectedPanic {
+				t.Errorf("expected panic %v, got %v", test.expectedPanic, recovered)
+			}
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			if w.Body.String() != test.expectedBody {
+				t.Errorf("expected body %q, got %q", test.expectedBody, w.Body.String())
+			}
+			if logs := strings.Count(buffer.String(), "\n"); logs != test.expectedLogs {
+				t.Errorf("expected %d logs, got %d", test.expectedLogs, logs)
+			}
+		})
+	}
+}
+
+func TestPanicRecoveryReport(t *testing.T) {
+	t.Parallel()
+
+	reporter := &testReporter{}
+	e := errs.New(
+		writeError,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		errs.WithPanicRecovery(),
+		errs.WithReporter(reporter),
+	)
+	handler := e.ToHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		panic(errSentinel)
+	})
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(reporter.reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reporter.reports))
+	}
+	report := reporter.reports[0]
+	if !errors.Is(report.Err, errSentinel) {
+		t.Errorf("expected reported error to wrap panic value, got %v", report.Err)
+	}
+	var panicErr errs.PanicError
+	if !errors.As(report.Err, &panicErr) {
+		t.Errorf("expected reported error to be PanicError, got %T", report.Err)
+	}
+	if !bytes.Contains(report.Stack, []byte("panic_test.go")) {
+		t.Errorf("expected stack to contain the panicking handler, got %s", report.Stack)
+	}
+}
+
+func TestPanicAfterWriteAbortsConnection(t *testing.T) {
+	t.Parallel()
+
+	e := errs.New(
+		writeError,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+		errs.WithPanicRecovery(),
+	)
+	server := httptest.NewServer(e.ToHandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) error {
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write([]byte("partial"))
+			_ = http.NewResponseController(w).Flush()
+			panic("oops!")
+		},
+	))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Errorf("expected reading the aborted response to fail")
+	}
+}
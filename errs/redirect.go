@@ -0,0 +1,80 @@
+package errs
+
+import (
+	"encoding/base64"
+	"net/http"
+)
+
+const flashCookieName = "flash"
+
+type Redirect struct {
+	URL    string
+	Status int
+	Flash  string
+	Reload bool
+}
+
+func NewRedirect(url string) Redirect {
+	return Redirect{
+		URL:    url,
+		Status: http.StatusSeeOther,
+	}
+}
+
+func (rd Redirect) WithFlash(flash string) Redirect {
+	rd.Flash = flash
+	return rd
+}
+
+func (rd Redirect) Error() string {
+	return "redirect to " + rd.URL
+}
+
+func (rd Redirect) write(w http.ResponseWriter, r *http.Request) {
+	if rd.Flash != "" {
+		http.SetCookie(w, &http.Cookie{
+			Name:     flashCookieName,
+			Value:    base64.RawURLEncoding.EncodeToString([]byte(rd.Flash)),
+			Path:     "/",
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		if rd.Reload {
+			w.Header().Set("HX-Redirect", rd.URL)
+		} else {
+			w.Header().Set("HX-Location", rd.URL)
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	status := rd.Status
+	if status == 0 {
+		status = http.StatusSeeOther
+	}
+	http.Redirect(w, r, rd.URL, status)
+}
+
+func Flash(w http.ResponseWriter, r *http.Request) (string, bool) {
+	cookie, err := r.Cookie(flashCookieName)
+	if err != nil {
+		return "", false
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     flashCookieName,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	flash, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return "", false
+	}
+	return string(flash), true
+}
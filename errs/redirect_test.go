@@ -0,0 +1,125 @@
+package errs_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fivethirty/middest/errs"
+)
+
+func TestRedirect(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		err              error
+		isHtmx           bool
+		expectedStatus   int
+		expectedLocation string
+		expectedHeader   string
+	}{
+		{
+			name:             "redirect should return 303",
+			err:              errs.NewRedirect("/items"),
+			expectedStatus:   http.StatusSeeOther,
+			expectedLocation: "/items",
+		},
+		{
+			name:             "redirect with status should use status",
+			err:              errs.Redirect{URL: "/items", Status: http.StatusFound},
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "/items",
+		},
+		{
+			name:             "wrapped redirect should return 303",
+			err:              fmt.Errorf("wrapped: %w", errs.NewRedirect("/items")),
+			expectedStatus:   http.StatusSeeOther,
+			expectedLocation: "/items",
+		},
+		{
+			name:           "htmx redirect should set HX-Location",
+			err:            errs.NewRedirect("/items"),
+			isHtmx:         true,
+			expectedStatus: http.StatusOK,
+			expectedHeader: "HX-Location",
+		},
+		{
+			name:           "htmx redirect with reload should set HX-Redirect",
+			err:            errs.Redirect{URL: "/items", Reload: true},
+			isHtmx:         true,
+			expectedStatus: http.StatusOK,
+			expectedHeader: "HX-Redirect",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buffer bytes.Buffer
+			e := errs.New(writeError, slog.New(slog.NewJSONHandler(&buffer, nil)))
+			handler := e.ToHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return test.err
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if test.isHtmx {
+				req.Header.Set("HX-Request", "true")
+			}
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			if got := w.Header().Get("Location"); got != test.expectedLocation {
+				t.Errorf("expected location %q, got %q", test.expectedLocation, got)
+			}
+			if test.expectedHeader != "" && w.Header().Get(test.expectedHeader) != "/items" {
+				t.Errorf("expected header %s to be set", test.expectedHeader)
+			}
+			if buffer.Len() != 0 {
+				t.Errorf("expected redirect not to be logged, got %s", buffer.String())
+			}
+		})
+	}
+}
+
+func TestFlash(t *testing.T) {
+	t.Parallel()
+
+	e := errs.New(writeError, slog.Default())
+	handler := e.ToHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return errs.NewRedirect("/items").WithFlash("Item saved!")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	req = httptest.NewRequest(http.MethodGet, "/items", nil)
+	for _, cookie := range w.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	w = httptest.NewRecorder()
+
+	flash, ok := errs.Flash(w, req)
+	if !ok || flash != "Item saved!" {
+		t.Errorf("expected flash %q, got %q", "Item saved!", flash)
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("expected flash cookie to be cleared, got %v", cookies)
+	}
+
+	_, ok = errs.Flash(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if ok {
+		t.Errorf("expected no flash without cookie")
+	}
+}
@@ -0,0 +1,39 @@
+package errs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/fivethirty/middest/ctxlog"
+)
+
+type contextKey string
+
+const errorRefKey contextKey = "error_ref"
+
+const errorRefLength = 8
+
+func ErrorRef(ctx context.Context) string {
+	if ref, ok := ctx.Value(errorRefKey).(string); ok {
+		return ref
+	}
+	if requestID, ok := ctxlog.RequestID(ctx); ok {
+		return requestID
+	}
+	return ""
+}
+
+func withErrorRef(r *http.Request) *http.Request {
+	if ErrorRef(r.Context()) != "" {
+		return r
+	}
+	return r.WithContext(context.WithValue(r.Context(), errorRefKey, newErrorRef()))
+}
+
+func newErrorRef() string {
+	b := make([]byte, errorRefLength)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,114 @@
+package errs_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fivethirty/middest/ctxlog"
+	"github.com/fivethirty/middest/errs"
+	"github.com/fivethirty/middest/internal/testhandler"
+)
+
+func writeErrorRef(w http.ResponseWriter, r *http.Request, code int, _ string) {
+	w.WriteHeader(code)
+	_, _ = w.Write([]byte(errs.ErrorRef(r.Context())))
+}
+
+func TestErrorRef(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		missingBody bool
+		withCtxlog  bool
+		expectedLog string
+	}{
+		{
+			name:        "ToHandlerFunc should generate ref",
+			expectedLog: "Handler Error",
+		},
+		{
+			name:        "ToHandlerFunc should use request ID",
+			withCtxlog:  true,
+			expectedLog: "Handler Error",
+		},
+		{
+			name:        "WriteMissingErrorBody should generate ref",
+			missingBody: true,
+			expectedLog: "Error Response",
+		},
+		{
+			name:        "WriteMissingErrorBody should use request ID",
+			missingBody: true,
+			withCtxlog:  true,
+			expectedLog: "Error Response",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := bytes.NewBuffer(nil)
+			e := errs.New(writeErrorRef, ctxlog.NewLogger(buffer))
+			var handler http.Handler = e.ToHandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) error {
+					return errors.New("boom")
+				},
+			)
+			if test.missingBody {
+				handler = e.WriteMissingErrorBody(testhandler.New(t, http.StatusNotFound, 0))
+			}
+
+			var requestID string
+			if test.withCtxlog {
+				inner := handler
+				handler = ctxlog.New(ctxlog.NewLogger(io.Discard))(
+					http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						requestID, _ = ctxlog.RequestID(r.Context())
+						inner.ServeHTTP(w, r)
+					}),
+				)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			ref := w.Body.String()
+			if ref == "" {
+				t.Fatal("expected error ref to be written")
+			}
+			if test.withCtxlog && ref != requestID {
+				t.Errorf("expected error ref %q to be request ID %q", ref, requestID)
+			}
+
+			var entry struct {
+				Message  string `json:"msg"`
+				ErrorRef string `json:"error_ref"`
+			}
+			if err := json.NewDecoder(buffer).Decode(&entry); err != nil {
+				t.Fatal(err)
+			}
+			if entry.Message != test.expectedLog {
+				t.Errorf("expected log %q, got %q", test.expectedLog, entry.Message)
+			}
+			if entry.ErrorRef != ref {
+				t.Errorf("expected logged error ref %q, got %q", ref, entry.ErrorRef)
+			}
+		})
+	}
+}
+
+func TestErrorRefWithoutError(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if ref := errs.ErrorRef(req.Context()); ref != "" {
+		t.Errorf("expected empty error ref, got %q", ref)
+	}
+}
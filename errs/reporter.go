@@ -0,0 +1,158 @@
+package errs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Report struct {
+	Err         error
+	Status      int
+	Method      string
+	URL         string
+	Stack       []byte
+	Fingerprint string
+	Ref         string
+	Time        time.Time
+	Suppressed  int
+}
+
+type Reporter interface {
+	Report(ctx context.Context, report Report)
+}
+
+type stacker interface {
+	Stack() []byte
+}
+
+const stackFramesInFingerprint = 3
+
+// Fingerprint groups errors by the types in their chain and either the
+// innermost frames of the stack or, without one, the innermost message.
+func Fingerprint(err error, stack []byte) string {
+	h := sha256.New()
+
+	var innermost error
+	for current := err; current != nil; current = errors.Unwrap(current) {
+		fmt.Fprintf(h, "%T\n", current)
+		innermost = current
+	}
+
+	frames := stackFrames(stack, stackFramesInFingerprint)
+	if len(frames) == 0 && innermost != nil {
+		fmt.Fprintln(h, innermost.Error())
+	}
+	for _, frame := range frames {
+		fmt.Fprintln(h, frame)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func stackFrames(stack []byte, limit int) []string {
+	if i := bytes.Index(stack, []byte("\npanic(")); i >= 0 {
+		stack = stack[i+1:]
+	}
+
+	frames := []string{}
+	for line := range bytes.Lines(stack) {
+		function := string(bytes.TrimSpace(line))
+		switch {
+		case len(frames) == limit:
+			return frames
+		case function == "",
+			line[0] == '\t',
+			strings.HasPrefix(function, "goroutine "),
+			strings.HasPrefix(function, "runtime/debug."),
+			strings.HasPrefix(function, "runtime."),
+			strings.HasPrefix(function, "panic("):
+			continue
+		}
+		if i := strings.LastIndex(function, "("); i > 0 {
+			function = function[:i]
+		}
+		frames = append(frames, function)
+	}
+	return frames
+}
+
+type rateLimitedReporter struct {
+	next      Reporter
+	interval  time.Duration
+	mu        sync.Mutex
+	seen      map[string]*rateLimitEntry
+	lastPrune time.Time
+}
+
+type rateLimitEntry struct {
+	forwarded  time.Time
+	suppressed int
+	last       Report
+}
+
+func NewRateLimitedReporter(next Reporter, interval time.Duration) Reporter {
+	return &rateLimitedReporter{
+		next:     next,
+		interval: interval,
+		seen:     map[string]*rateLimitEntry{},
+	}
+}
+
+func (rl *rateLimitedReporter) Report(ctx context.Context, report Report) {
+	rl.mu.Lock()
+	flushed := rl.prune(report.Time, report.Fingerprint)
+
+	entry, ok := rl.seen[report.Fingerprint]
+	suppress := ok && report.Time.Sub(entry.forwarded) < rl.interval
+	if suppress {
+		entry.suppressed++
+		entry.last = report
+	} else {
+		if !ok {
+			entry = &rateLimitEntry{}
+			rl.seen[report.Fingerprint] = entry
+		}
+		report.Suppressed = entry.suppressed
+		entry.forwarded = report.Time
+		entry.suppressed = 0
+	}
+	rl.mu.Unlock()
+
+	for _, flush := range flushed {
+		rl.next.Report(context.Background(), flush)
+	}
+	if !suppress {
+		rl.next.Report(ctx, report)
+	}
+}
+
+// prune runs at most once per interval and evicts expired fingerprints,
+// returning a final report for those that still had suppressed reports.
+// current is kept since the report being handled carries its count.
+func (rl *rateLimitedReporter) prune(now time.Time, current string) []Report {
+	if now.Sub(rl.lastPrune) < rl.interval {
+		return nil
+	}
+	rl.lastPrune = now
+
+	flushed := []Report{}
+	for fingerprint, entry := range rl.seen {
+		if fingerprint == current || now.Sub(entry.forwarded) < rl.interval {
+			continue
+		}
+		if entry.suppressed > 0 {
+			report := entry.last
+			report.Suppressed = entry.suppressed
+			flushed = append(flushed, report)
+		}
+		delete(rl.seen, fingerprint)
+	}
+	return flushed
+}
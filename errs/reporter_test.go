@@ -0,0 +1,204 @@
+package errs_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/middest/errs"
+)
+
+type testReporter struct {
+	mu      sync.Mutex
+	reports []errs.Report
+}
+
+func (tr *testReporter) Report(_ context.Context, report errs.Report) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	tr.reports = append(tr.reports, report)
+}
+
+func TestReporter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		handler         errs.HttpHandlerWithError
+		expectedReports int
+		expectedStatus  int
+		expectStack     bool
+	}{
+		{
+			name: "plain error should be reported",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return errors.New("boom")
+			},
+			expectedReports: 1,
+			expectedStatus:  http.StatusInternalServerError,
+		},
+		{
+			name: "5xx StatusError should be reported",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return errs.NewStatusError(errors.New("boom"), http.StatusBadGateway, "")
+			},
+			expectedReports: 1,
+			expectedStatus:  http.StatusBadGateway,
+		},
+		{
+			name: "4xx StatusError should not be reported",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return errs.NewStatusError(errors.New("boom"), http.StatusBadRequest, "")
+			},
+			expectedReports: 0,
+			expectedStatus:  http.StatusBadRequest,
+		},
+		{
+			name: "panic should be reported with stack",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				panic("oops!")
+			},
+			expectedReports: 1,
+			expectedStatus:  http.StatusInternalServerError,
+			expectStack:     true,
+		},
+		{
+			name: "abort should not be reported",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				panic(http.ErrAbortHandler)
+			},
+			expectedReports: 0,
+			expectedStatus:  http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			reporter := &testReporter{}
+			e := errs.New(writeError, slog.Default(), errs.WithReporter(reporter))
+			handler := e.WriteMissingErrorBody(e.ToHandlerFunc(test.handler))
+
+			req := httptest.NewRequest(http.MethodGet, "/path", nil)
+			w := httptest.NewRecorder()
+			func() {
+				defer func() {
+					_ = recover()
+				}()
+				handler.ServeHTTP(w, req)
+			}()
+
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			if len(reporter.reports) != test.expectedReports {
+				t.Fatalf("expected %d reports, got %d", test.expectedReports, len(reporter.reports))
+			}
+			if test.expectedReports == 0 {
+				return
+			}
+			report := reporter.reports[0]
+			if report.Status != test.expectedStatus {
+				t.Errorf("expected report status %d, got %d", test.expectedStatus, report.Status)
+			}
+			if report.Method != http.MethodGet || report.URL != "/path" {
+				t.Errorf("expected request metadata, got %s %s", report.Method, report.URL)
+			}
+			if report.Fingerprint == "" {
+				t.Errorf("expected fingerprint to be set")
+			}
+			if test.expectStack && len(report.Stack) == 0 {
+				t.Errorf("expected stack to be set")
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Parallel()
+
+	errA := fmt.Errorf("loading user: %w", errors.New("not found"))
+	errB := fmt.Errorf("loading user: %w", errors.New("not found"))
+	errC := fmt.Errorf("loading user: %w", errors.New("timeout"))
+
+	if errs.Fingerprint(errA, nil) != errs.Fingerprint(errB, nil) {
+		t.Errorf("expected equal errors to have equal fingerprints")
+	}
+	if errs.Fingerprint(errA, nil) == errs.Fingerprint(errC, nil) {
+		t.Errorf("expected different errors to have different fingerprints")
+	}
+
+	stackA := []byte("goroutine 1 [running]:\nmain.a(0xc000010000)\n\t/a.go:1 +0x1\n")
+	stackB := []byte("goroutine 7 [running]:\nmain.a(0xc000020000)\n\t/a.go:1 +0x1\n")
+	stackC := []byte("goroutine 1 [running]:\nmain.b(0xc000010000)\n\t/b.go:1 +0x1\n")
+	if errs.Fingerprint(errA, stackA) != errs.Fingerprint(errC, stackB) {
+		t.Errorf("expected equal stacks to have equal fingerprints")
+	}
+	if errs.Fingerprint(errA, stackA) == errs.Fingerprint(errA, stackC) {
+		t.Errorf("expected different stacks to have different fingerprints")
+	}
+}
+
+func TestRateLimitedReporter(t *testing.T) {
+	t.Parallel()
+
+	next := &testReporter{}
+	reporter := errs.NewRateLimitedReporter(next, time.Minute)
+	start := time.Now()
+
+	reports := []errs.Report{
+		{Fingerprint: "a", Time: start},
+		{Fingerprint: "a", Time: start.Add(time.Second)},
+		{Fingerprint: "b", Time: start.Add(2 * time.Second)},
+		{Fingerprint: "a", Time: start.Add(3 * time.Second)},
+		{Fingerprint: "a", Time: start.Add(2 * time.Minute)},
+	}
+	for _, report := range reports {
+		reporter.Report(context.Background(), report)
+	}
+
+	if len(next.reports) != 3 {
+		t.Fatalf("expected 3 forwarded reports, got %d", len(next.reports))
+	}
+	last := next.reports[2]
+	if last.Fingerprint != "a" || last.Suppressed != 2 {
+		t.Errorf("expected 2 suppressed reports for a, got %d", last.Suppressed)
+	}
+}
+
+func TestRateLimitedReporterFlushesSuppressed(t *testing.T) {
+	t.Parallel()
+
+	next := &testReporter{}
+	reporter := errs.NewRateLimitedReporter(next, time.Minute)
+	start := time.Now()
+
+	reports := []errs.Report{
+		{Fingerprint: "a", Time: start},
+		{Fingerprint: "a", Time: start.Add(time.Second)},
+		{Fingerprint: "a", Time: start.Add(2 * time.Second)},
+		{Fingerprint: "b", Time: start.Add(2 * time.Minute)},
+		{Fingerprint: "a", Time: start.Add(3 * time.Minute)},
+	}
+	for _, report := range reports {
+		reporter.Report(context.Background(), report)
+	}
+
+	if len(next.reports) != 4 {
+		t.Fatalf("expected 4 forwarded reports, got %d", len(next.reports))
+	}
+	flushed := next.reports[1]
+	if flushed.Fingerprint != "a" || flushed.Suppressed != 2 {
+		t.Errorf("expected flushed report for a with 2 suppressed, got %+v", flushed)
+	}
+	if last := next.reports[3]; last.Suppressed != 0 {
+		t.Errorf("expected suppressed count to be reset after flush, got %d", last.Suppressed)
+	}
+}
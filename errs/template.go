@@ -0,0 +1,84 @@
+package errs
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+)
+
+const fallbackTemplateName = "error.html"
+
+type ErrorPage struct {
+	Status     int
+	StatusText string
+	Message    string
+	RequestID  string
+	IsHtmx     bool
+}
+
+// NewTemplateErrorWriter parses the templates matching patterns in fsys and
+// renders the first of "404.html", "4xx.html" and "error.html" that exists
+// for the response status.
+func NewTemplateErrorWriter(fsys fs.FS, patterns ...string) (ErrorWriter, error) {
+	templates, err := template.ParseFS(fsys, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, code int, responseMessage string) {
+		tmpl := lookupTemplate(templates, code)
+		if tmpl == nil {
+			WritePlainText(w, r, code, responseMessage)
+			return
+		}
+
+		page := ErrorPage{
+			Status:     code,
+			StatusText: http.StatusText(code),
+			Message:    responseMessage,
+			RequestID:  ErrorRef(r.Context()),
+			IsHtmx:     r.Header.Get("HX-Request") == "true",
+		}
+
+		var buffer bytes.Buffer
+		if err := tmpl.Execute(&buffer, page); err != nil {
+			WritePlainText(w, r, code, responseMessage)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(code)
+		_, _ = w.Write(buffer.Bytes())
+	}, nil
+}
+
+func lookupTemplate(templates *template.Template, code int) *template.Template {
+	names := []string{
+		fmt.Sprintf("%d.html", code),
+		fmt.Sprintf("%dxx.html", code/100),
+		fallbackTemplateName,
+	}
+	for _, name := range names {
+		if tmpl := templates.Lookup(name); tmpl != nil {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+func WritePlainText(w http.ResponseWriter, r *http.Request, code int, responseMessage string) {
+	message := responseMessage
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	if ref := ErrorRef(r.Context()); ref != "" {
+		message = fmt.Sprintf("%s (ref: %s)", message, ref)
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	_, _ = fmt.Fprintln(w, message)
+}
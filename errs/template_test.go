@@ -0,0 +1,127 @@
+package errs_test
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fivethirty/middest/errs"
+)
+
+var templates = fstest.MapFS{
+	"errors/404.html": {
+		Data: []byte(`not found: {{.Message}} {{if .IsHtmx}}htmx{{end}} {{.RequestID}}`),
+	},
+	"errors/4xx.html": {
+		Data: []byte(`client error {{.Status}} {{.StatusText}}`),
+	},
+	"errors/500.html": {
+		Data: []byte(`{{.Missing.Field}}`),
+	},
+}
+
+func TestTemplateErrorWriter(t *testing.T) {
+	t.Parallel()
+
+	writeError, err := errs.NewTemplateErrorWriter(templates, "errors/*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notFound := errs.NewStatusError(errors.New("nope"), http.StatusNotFound, "item")
+
+	tests := []struct {
+		name                string
+		err                 error
+		isHtmx              bool
+		expectedStatus      int
+		expectedBody        string
+		expectedContentType string
+	}{
+		{
+			name:                "exact status template should render",
+			err:                 notFound,
+			expectedStatus:      http.StatusNotFound,
+			expectedBody:        "not found: item  ",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			name:                "htmx requests should be flagged",
+			err:                 notFound,
+			isHtmx:              true,
+			expectedStatus:      http.StatusNotFound,
+			expectedBody:        "not found: item htmx ",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			name:                "status class template should render",
+			err:                 errs.NewStatusError(errors.New("nope"), http.StatusConflict, ""),
+			expectedStatus:      http.StatusConflict,
+			expectedBody:        "client error 409 Conflict",
+			expectedContentType: "text/html; charset=utf-8",
+		},
+		{
+			name:                "failed render should fall back to plain text",
+			err:                 errors.New("boom"),
+			expectedStatus:      http.StatusInternalServerError,
+			expectedBody:        "Internal Server Error (ref: ",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name: "missing template should fall back to plain text",
+			err: errs.NewStatusError(
+				errors.New("boom"),
+				http.StatusBadGateway,
+				"upstream down",
+			),
+			expectedStatus:      http.StatusBadGateway,
+			expectedBody:        "upstream down (ref: ",
+			expectedContentType: "text/plain; charset=utf-8",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			e := errs.New(writeError, slog.Default())
+			handler := e.ToHandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				return test.err
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.isHtmx {
+				req.Header.Set("HX-Request", "true")
+			}
+			w := httptest.NewRecorder()
+			handler(w, req)
+
+			if w.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, w.Code)
+			}
+			if !strings.HasPrefix(w.Body.String(), test.expectedBody) {
+				t.Errorf(
+					"expected body to start with %q, got %q",
+					test.expectedBody,
+					w.Body.String(),
+				)
+			}
+			if got := w.Header().Get("Content-Type"); got != test.expectedContentType {
+				t.Errorf("expected content type %q, got %q", test.expectedContentType, got)
+			}
+		})
+	}
+}
+
+func TestTemplateErrorWriterParseError(t *testing.T) {
+	t.Parallel()
+
+	_, err := errs.NewTemplateErrorWriter(templates, "missing/*.html")
+	if err == nil {
+		t.Error("expected error for pattern without matches")
+	}
+}
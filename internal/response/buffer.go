@@ -0,0 +1,96 @@
+package response
+
+import (
+	"bytes"
+	"net/http"
+)
+
+type BufferedResponseWriter struct {
+	http.ResponseWriter
+	header      http.Header
+	status      int
+	buffer      bytes.Buffer
+	maxBytes    int
+	IsCommitted bool
+}
+
+func Buffer(w http.ResponseWriter, maxBytes int) *BufferedResponseWriter {
+	return &BufferedResponseWriter{
+		ResponseWriter: w,
+		header:         http.Header{},
+		maxBytes:       maxBytes,
+	}
+}
+
+func (bw *BufferedResponseWriter) Header() http.Header {
+	if bw.IsCommitted {
+		return bw.ResponseWriter.Header()
+	}
+	return bw.header
+}
+
+func (bw *BufferedResponseWriter) WriteHeader(code int) {
+	if bw.IsCommitted {
+		bw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if bw.status == 0 {
+		bw.status = code
+	}
+}
+
+func (bw *BufferedResponseWriter) Write(body []byte) (int, error) {
+	if !bw.IsCommitted && bw.buffer.Len()+len(body) > bw.maxBytes {
+		if err := bw.Commit(); err != nil {
+			return 0, err
+		}
+	}
+	if bw.IsCommitted {
+		return bw.ResponseWriter.Write(body)
+	}
+	if bw.status == 0 {
+		bw.status = http.StatusOK
+	}
+	return bw.buffer.Write(body)
+}
+
+func (bw *BufferedResponseWriter) Commit() error {
+	if bw.IsCommitted {
+		return nil
+	}
+	bw.IsCommitted = true
+
+	header := bw.ResponseWriter.Header()
+	for key, values := range bw.header {
+		header[key] = values
+	}
+	if bw.status != 0 {
+		bw.ResponseWriter.WriteHeader(bw.status)
+	}
+	if bw.buffer.Len() == 0 {
+		return nil
+	}
+	_, err := bw.ResponseWriter.Write(bw.buffer.Bytes())
+	bw.buffer.Reset()
+	return err
+}
+
+func (bw *BufferedResponseWriter) Discard() {
+	if bw.IsCommitted {
+		return
+	}
+	bw.header = http.Header{}
+	bw.status = 0
+	bw.buffer.Reset()
+}
+
+func (bw *BufferedResponseWriter) Flush() {
+	if err := bw.Commit(); err != nil {
+		return
+	}
+	_ = http.NewResponseController(bw.ResponseWriter).Flush()
+}
+
+func (bw *BufferedResponseWriter) Unwrap() http.ResponseWriter {
+	return bw.ResponseWriter
+}
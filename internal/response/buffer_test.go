@@ -0,0 +1,103 @@
+package response_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fivethirty/middest/internal/response"
+)
+
+func TestBufferedResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name              string
+		fn                func(*response.BufferedResponseWriter)
+		expectedStatus    int
+		expectedBody      string
+		expectedHeader    string
+		expectedCommitted bool
+	}{
+		{
+			name: "writes should be held until commit",
+			fn: func(w *response.BufferedResponseWriter) {
+				w.Header().Set("X-Test", "value")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("hello"))
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "commit should write held response",
+			fn: func(w *response.BufferedResponseWriter) {
+				w.Header().Set("X-Test", "value")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("hello"))
+				_ = w.Commit()
+			},
+			expectedStatus:    http.StatusCreated,
+			expectedBody:      "hello",
+			expectedHeader:    "value",
+			expectedCommitted: true,
+		},
+		{
+			name: "discard should drop held response",
+			fn: func(w *response.BufferedResponseWriter) {
+				w.Header().Set("X-Test", "value")
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte("hello"))
+				w.Discard()
+				_ = w.Commit()
+			},
+			expectedStatus:    http.StatusOK,
+			expectedCommitted: true,
+		},
+		{
+			name: "exceeding max bytes should commit",
+			fn: func(w *response.BufferedResponseWriter) {
+				w.Header().Set("X-Test", "value")
+				_, _ = w.Write([]byte("hello"))
+				_, _ = w.Write([]byte(" world"))
+				w.Discard()
+			},
+			expectedStatus:    http.StatusOK,
+			expectedBody:      "hello world",
+			expectedHeader:    "value",
+			expectedCommitted: true,
+		},
+		{
+			name: "flush should commit",
+			fn: func(w *response.BufferedResponseWriter) {
+				_, _ = w.Write([]byte("hello"))
+				w.Flush()
+			},
+			expectedStatus:    http.StatusOK,
+			expectedBody:      "hello",
+			expectedCommitted: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := httptest.NewRecorder()
+			w := response.Buffer(recorder, 8)
+			test.fn(w)
+
+			if recorder.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, recorder.Code)
+			}
+			if recorder.Body.String() != test.expectedBody {
+				t.Errorf("expected body %q, got %q", test.expectedBody, recorder.Body.String())
+			}
+			if got := recorder.Header().Get("X-Test"); got != test.expectedHeader {
+				t.Errorf("expected header %q, got %q", test.expectedHeader, got)
+			}
+			if w.IsCommitted != test.expectedCommitted {
+				t.Errorf("expected committed %v, got %v", test.expectedCommitted, w.IsCommitted)
+			}
+		})
+	}
+}
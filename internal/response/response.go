@@ -32,3 +32,7 @@ func (rw *ResponseWriter) Write(body []byte) (int, error) {
 	rw.IsHeaderWritten = true
 	return bytesWritten, err
 }
+
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
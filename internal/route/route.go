@@ -0,0 +1,16 @@
+package route
+
+import "net/http"
+
+// Pattern returns the ServeMux pattern matching r. Middleware that runs
+// before the mux has routed the request only finds it when next is the mux.
+func Pattern(r *http.Request, next http.Handler) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	if mux, ok := next.(*http.ServeMux); ok {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package route_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fivethirty/middest/internal/route"
+)
+
+func TestPattern(t *testing.T) {
+	t.Parallel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name     string
+		next     http.Handler
+		path     string
+		pattern  string
+		expected string
+	}{
+		{
+			name:     "request pattern should be used",
+			next:     http.NotFoundHandler(),
+			path:     "/items/1",
+			pattern:  "GET /items/{id}",
+			expected: "GET /items/{id}",
+		},
+		{
+			name:     "mux should be asked when next is the mux",
+			next:     mux,
+			path:     "/items/1",
+			expected: "GET /items/{id}",
+		},
+		{
+			name:     "unmatched route should return empty pattern",
+			next:     mux,
+			path:     "/missing",
+			expected: "",
+		},
+		{
+			name:     "other handlers should return empty pattern",
+			next:     http.NotFoundHandler(),
+			path:     "/items/1",
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Pattern = test.pattern
+			if got := route.Pattern(req, test.next); got != test.expected {
+				t.Errorf("expected pattern %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
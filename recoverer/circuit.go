@@ -0,0 +1,179 @@
+package recoverer
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fivethirty/middest/errs"
+	"github.com/fivethirty/middest/internal/response"
+)
+
+// maxCircuitRoutes bounds the routes a Circuit tracks at once. Routes are
+// ServeMux patterns, so hitting it means something is badly wrong anyway.
+const maxCircuitRoutes = 1024
+
+type Circuit struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+	onTrip    func(route string, count int)
+	mu        sync.Mutex
+	routes    map[string]*routeState
+	lastSweep time.Time
+}
+
+type routeState struct {
+	panics    []time.Time
+	tripped   bool
+	openUntil time.Time
+}
+
+type CircuitOption func(*Circuit)
+
+func WithOnTrip(onTrip func(route string, count int)) CircuitOption {
+	return func(c *Circuit) {
+		c.onTrip = onTrip
+	}
+}
+
+// WithCooldown answers requests to a tripped route with a 503 for cooldown
+// instead of calling the handler.
+func WithCooldown(cooldown time.Duration) CircuitOption {
+	return func(c *Circuit) {
+		c.cooldown = cooldown
+	}
+}
+
+// NewCircuit trips a route once it panics threshold times within window.
+// Routes are ServeMux patterns, so recoverer has to run after the mux or wrap
+// it directly; requests without a pattern aren't tracked.
+func NewCircuit(threshold int, window time.Duration, opts ...CircuitOption) *Circuit {
+	c := &Circuit{
+		threshold: threshold,
+		window:    window,
+		routes:    map[string]*routeState{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func WithCircuit(circuit *Circuit) Option {
+	return func(c *config) {
+		c.circuit = circuit
+	}
+}
+
+func (c *Circuit) Counts() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sweep(time.Now())
+	counts := map[string]int{}
+	for key, state := range c.routes {
+		if len(state.panics) > 0 {
+			counts[key] = len(state.panics)
+		}
+	}
+	return counts
+}
+
+func (c *Circuit) record(key string) {
+	c.mu.Lock()
+	now := time.Now()
+	if now.Sub(c.lastSweep) >= c.window {
+		c.sweep(now)
+	}
+	state, ok := c.routes[key]
+	if !ok {
+		if len(c.routes) >= maxCircuitRoutes {
+			c.sweep(now)
+		}
+		if len(c.routes) >= maxCircuitRoutes {
+			c.mu.Unlock()
+			return
+		}
+		state = &routeState{}
+		c.routes[key] = state
+	}
+	state.panics = append(state.panics, now)
+	count := c.prune(state, now)
+
+	// A route still panicking once its cooldown is over trips again.
+	reopen := c.cooldown > 0 && !now.Before(state.openUntil)
+	trip := count >= c.threshold && (!state.tripped || reopen)
+	if trip {
+		state.tripped = true
+		state.openUntil = now.Add(c.cooldown)
+	}
+	c.mu.Unlock()
+
+	if trip && c.onTrip != nil {
+		c.onTrip(key, count)
+	}
+}
+
+func (c *Circuit) retryAfter(key string) (time.Duration, bool) {
+	if c.cooldown <= 0 {
+		return 0, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.routes[key]
+	if !ok {
+		return 0, false
+	}
+	now := time.Now()
+	if c.prune(state, now) == 0 && !state.tripped {
+		delete(c.routes, key)
+		return 0, false
+	}
+	remaining := state.openUntil.Sub(now)
+	return remaining, remaining > 0
+}
+
+// sweep drops routes without panics in the window that aren't tripped.
+func (c *Circuit) sweep(now time.Time) {
+	c.lastSweep = now
+	for key, state := range c.routes {
+		if c.prune(state, now) == 0 && !state.tripped {
+			delete(c.routes, key)
+		}
+	}
+}
+
+func (c *Circuit) prune(state *routeState, now time.Time) int {
+	i := 0
+	for i < len(state.panics) && now.Sub(state.panics[i]) > c.window {
+		i++
+	}
+	state.panics = state.panics[i:]
+	if state.tripped && len(state.panics) < c.threshold && !now.Before(state.openUntil) {
+		state.tripped = false
+	}
+	return len(state.panics)
+}
+
+func (c *config) shortCircuit(w *response.ResponseWriter, r *http.Request, key string) bool {
+	if c.circuit == nil || key == "" {
+		return false
+	}
+	retryAfter, open := c.circuit.retryAfter(key)
+	if !open {
+		return false
+	}
+
+	writeError := c.writeError
+	if writeError == nil {
+		writeError = errs.WritePlainText
+	}
+	seconds := int64((retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	writeError(w, r, http.StatusServiceUnavailable, "")
+	return true
+}
@@ -0,0 +1,235 @@
+package recoverer_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/middest/recoverer"
+)
+
+func TestCircuit(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		cooldown        time.Duration
+		requests        int
+		expectedTrips   int
+		expectedCount   int
+		expectedLast    int
+		expectedHandled int
+	}{
+		{
+			name:            "panics under threshold should not trip",
+			requests:        2,
+			expectedTrips:   0,
+			expectedCount:   2,
+			expectedLast:    http.StatusOK,
+			expectedHandled: 2,
+		},
+		{
+			name:            "panics over threshold should trip once",
+			requests:        5,
+			expectedTrips:   1,
+			expectedCount:   5,
+			expectedLast:    http.StatusOK,
+			expectedHandled: 5,
+		},
+		{
+			name:            "tripped route with cooldown should return 503",
+			cooldown:        time.Minute,
+			requests:        5,
+			expectedTrips:   1,
+			expectedCount:   3,
+			expectedLast:    http.StatusServiceUnavailable,
+			expectedHandled: 3,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var mu sync.Mutex
+			trips := 0
+			circuit := recoverer.NewCircuit(
+				3,
+				time.Minute,
+				recoverer.WithCooldown(test.cooldown),
+				recoverer.WithOnTrip(func(route string, count int) {
+					mu.Lock()
+					defer mu.Unlock()
+					trips++
+					if route != "GET /panic" || count != 3 {
+						t.Errorf("expected trip for GET /panic at 3, got %s at %d", route, count)
+					}
+				}),
+			)
+
+			handled := 0
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+				handled++
+				panic("oops!")
+			})
+			mux.HandleFunc("GET /ok", func(w http.ResponseWriter, r *http.Request) {})
+			handler := recoverer.New(&testLogger{}, recoverer.WithCircuit(circuit))(mux)
+
+			var last int
+			for range test.requests {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+				last = w.Code
+			}
+
+			if trips != test.expectedTrips {
+				t.Errorf("expected %d trips, got %d", test.expectedTrips, trips)
+			}
+			if handled != test.expectedHandled {
+				t.Errorf("expected %d handled requests, got %d", test.expectedHandled, handled)
+			}
+			if last != test.expectedLast {
+				t.Errorf("expected last status %d, got %d", test.expectedLast, last)
+			}
+			if count := circuit.Counts()["GET /panic"]; count != test.expectedCount {
+				t.Errorf("expected count %d, got %d", test.expectedCount, count)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("expected other routes to stay available, got %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestCircuitCooldown(t *testing.T) {
+	t.Parallel()
+
+	circuit := recoverer.NewCircuit(1, time.Minute, recoverer.WithCooldown(20*time.Millisecond))
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("oops!")
+		}
+	})
+	handler := recoverer.New(&testLogger{}, recoverer.WithCircuit(circuit))(mux)
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w
+	}
+
+	serve()
+	w := serve()
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected 503 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if w := serve(); w.Code != http.StatusOK {
+		t.Errorf("expected route to recover after cooldown, got %d", w.Code)
+	}
+}
+
+func TestCircuitTripsAgainAfterCooldown(t *testing.T) {
+	t.Parallel()
+
+	trips := 0
+	circuit := recoverer.NewCircuit(
+		2,
+		time.Minute,
+		recoverer.WithCooldown(20*time.Millisecond),
+		recoverer.WithOnTrip(func(route string, count int) {
+			trips++
+		}),
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		panic("oops!")
+	})
+	handler := recoverer.New(&testLogger{}, recoverer.WithCircuit(circuit))(mux)
+
+	serve := func() int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		return w.Code
+	}
+
+	codes := []int{serve(), serve(), serve()}
+	time.Sleep(30 * time.Millisecond)
+	codes = append(codes, serve(), serve())
+
+	expected := []int{
+		http.StatusOK,
+		http.StatusOK,
+		http.StatusServiceUnavailable,
+		http.StatusOK,
+		http.StatusServiceUnavailable,
+	}
+	if !slices.Equal(codes, expected) {
+		t.Errorf("expected status codes %v, got %v", expected, codes)
+	}
+	if trips != 2 {
+		t.Errorf("expected route to trip again after cooldown, got %d trips", trips)
+	}
+}
+
+func TestCircuitKeysByPattern(t *testing.T) {
+	t.Parallel()
+
+	trips := 0
+	circuit := recoverer.NewCircuit(
+		3,
+		time.Minute,
+		recoverer.WithOnTrip(func(route string, count int) {
+			trips++
+			if route != "GET /items/{id}" {
+				t.Errorf("expected trip for GET /items/{id}, got %s", route)
+			}
+		}),
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		panic("oops!")
+	})
+	handler := recoverer.New(&testLogger{}, recoverer.WithCircuit(circuit))(mux)
+
+	for _, path := range []string{"/items/1", "/items/2", "/items/3"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if trips != 1 {
+		t.Errorf("expected parameterised paths to trip once, got %d", trips)
+	}
+	if counts := circuit.Counts(); len(counts) != 1 {
+		t.Errorf("expected one tracked route, got %v", counts)
+	}
+}
+
+func TestCircuitWithoutPattern(t *testing.T) {
+	t.Parallel()
+
+	circuit := recoverer.NewCircuit(1, time.Minute, recoverer.WithCooldown(time.Minute))
+	handler := recoverer.New(&testLogger{}, recoverer.WithCircuit(circuit))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("oops!")
+		}),
+	)
+
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	if counts := circuit.Counts(); len(counts) != 0 {
+		t.Errorf("expected requests without a pattern not to be tracked, got %v", counts)
+	}
+}
@@ -0,0 +1,70 @@
+package recoverer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type Group struct {
+	logger logger
+	config *config
+	wg     sync.WaitGroup
+}
+
+func NewGroup(l logger, opts ...Option) *Group {
+	return &Group{
+		logger: l,
+		config: newConfig(opts),
+	}
+}
+
+// Go runs fn in a goroutine and logs any panic with the attributes of ctx
+// instead of crashing the process.
+func (g *Group) Go(ctx context.Context, fn func(context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer g.config.recoverGoroutine(ctx, g.logger)
+		fn(ctx)
+	}()
+}
+
+// GoDetached is like Go but fn keeps running after ctx is canceled, which
+// is what work started by a request usually needs.
+func (g *Group) GoDetached(ctx context.Context, fn func(context.Context)) {
+	g.Go(context.WithoutCancel(ctx), fn)
+}
+
+func (g *Group) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func Go(ctx context.Context, l logger, fn func(context.Context), opts ...Option) {
+	NewGroup(l, opts...).Go(ctx, fn)
+}
+
+func (c *config) recoverGoroutine(ctx context.Context, l logger) {
+	err := recover()
+	if err == nil {
+		return
+	}
+	if c.isAbort(err) {
+		l.Log(ctx, slog.LevelInfo, "Aborted", "reason", err)
+		return
+	}
+
+	attrs := append(panicAttrs(err), stackAttr(stack(c.stackDepth)))
+	l.Log(ctx, slog.LevelError, "Goroutine Panic", attrs...)
+}
@@ -0,0 +1,137 @@
+package recoverer_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/middest/ctxlog"
+	"github.com/fivethirty/middest/recoverer"
+)
+
+type lockedBuffer struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (lb *lockedBuffer) Write(p []byte) (int, error) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	return lb.buffer.Write(p)
+}
+
+func TestGroup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		fn            func(context.Context)
+		detach        bool
+		expectedMsg   string
+		expectedLevel string
+	}{
+		{
+			name:          "panic should be logged with request attributes",
+			fn:            func(context.Context) { panic("oops!") },
+			expectedMsg:   "Goroutine Panic",
+			expectedLevel: slog.LevelError.String(),
+		},
+		{
+			name: "abort should be logged as info",
+			fn: func(context.Context) {
+				panic(errShutdown)
+			},
+			expectedMsg:   "Aborted",
+			expectedLevel: slog.LevelInfo.String(),
+		},
+		{
+			name:   "detached goroutine should outlive canceled context",
+			detach: true,
+			fn: func(ctx context.Context) {
+				if ctx.Err() != nil {
+					panic("context canceled")
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := &lockedBuffer{}
+			group := recoverer.NewGroup(
+				ctxlog.NewLogger(buffer),
+				recoverer.WithAbortSentinels(errShutdown),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			ctx = ctxlog.AppendCtx(ctx, slog.String("request_id", "abc"))
+			if test.detach {
+				cancel()
+				group.GoDetached(ctx, test.fn)
+			} else {
+				defer cancel()
+				group.Go(ctx, test.fn)
+			}
+
+			if err := group.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			if test.expectedMsg == "" {
+				if buffer.buffer.Len() != 0 {
+					t.Errorf("expected no logs, got %s", buffer.buffer.String())
+				}
+				return
+			}
+
+			var entry struct {
+				Level     string `json:"level"`
+				Message   string `json:"msg"`
+				RequestID string `json:"request_id"`
+			}
+			if err := json.NewDecoder(&buffer.buffer).Decode(&entry); err != nil {
+				t.Fatal(err)
+			}
+			if entry.Message != test.expectedMsg || entry.Level != test.expectedLevel {
+				t.Errorf(
+					"expected %s %q, got %s %q",
+					test.expectedLevel,
+					test.expectedMsg,
+					entry.Level,
+					entry.Message,
+				)
+			}
+			if entry.RequestID != "abc" {
+				t.Errorf("expected request_id abc, got %q", entry.RequestID)
+			}
+		})
+	}
+}
+
+func TestGroupWait(t *testing.T) {
+	t.Parallel()
+
+	group := recoverer.NewGroup(&testLogger{})
+	release := make(chan struct{})
+	group.Go(context.Background(), func(context.Context) {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := group.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	close(release)
+	if err := group.Wait(context.Background()); err != nil {
+		t.Errorf("expected wait to succeed, got %v", err)
+	}
+}
@@ -2,30 +2,131 @@ package recoverer
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
-	"runtime/debug"
+	"reflect"
+
+	"github.com/fivethirty/middest/errs"
+	"github.com/fivethirty/middest/internal/response"
+	"github.com/fivethirty/middest/internal/route"
 )
 
 type logger interface {
 	Log(ctx context.Context, level slog.Level, msg string, keysAndValues ...any)
 }
 
-func New(l logger) func(http.Handler) http.Handler {
+type config struct {
+	writeError     errs.ErrorWriter
+	abortSentinels []any
+	stackDepth     int
+	circuit        *Circuit
+}
+
+type Option func(*config)
+
+// WithErrorWriter responds to recovered panics with writeError. If the
+// handler already started the response, the connection is aborted instead.
+func WithErrorWriter(writeError errs.ErrorWriter) Option {
+	return func(c *config) {
+		c.writeError = writeError
+	}
+}
+
+// WithPlainTextError responds to recovered panics with a plain text 500.
+func WithPlainTextError() Option {
+	return WithErrorWriter(errs.WritePlainText)
+}
+
+// WithAbortSentinels treats panics with any of sentinels like
+// http.ErrAbortHandler: they are logged without a stack and abort the
+// connection.
+func WithAbortSentinels(sentinels ...any) Option {
+	return func(c *config) {
+		c.abortSentinels = append(c.abortSentinels, sentinels...)
+	}
+}
+
+func newConfig(opts []Option) *config {
+	c := &config{
+		abortSentinels: []any{http.ErrAbortHandler},
+		stackDepth:     defaultStackDepth,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func New(l logger, opts ...Option) func(http.Handler) http.Handler {
+	c := newConfig(opts)
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			wrapped, ok := w.(*response.ResponseWriter)
+			if !ok {
+				wrapped = response.Wrap(w)
+			}
+
+			key := ""
+			if c.circuit != nil {
+				key = route.Pattern(r, h)
+			}
+			if c.shortCircuit(wrapped, r, key) {
+				return
+			}
+
 			defer func() {
-				if err := recover(); err != nil {
-					l.Log(
-						r.Context(),
-						slog.LevelError,
-						"Panic",
-						"error", err,
-						"trace", string(debug.Stack()),
-					)
+				err := recover()
+				if err == nil {
+					return
+				}
+				if c.isAbort(err) {
+					l.Log(r.Context(), slog.LevelInfo, "Aborted", "reason", err)
+					panic(http.ErrAbortHandler)
+				}
+
+				attrs := append(panicAttrs(err), stackAttr(stack(c.stackDepth)))
+				l.Log(r.Context(), slog.LevelError, "Panic", attrs...)
+				if c.circuit != nil && key != "" {
+					c.circuit.record(key)
 				}
+				c.respond(wrapped, r)
 			}()
-			h.ServeHTTP(w, r)
+			h.ServeHTTP(wrapped, r)
 		})
 	}
 }
+
+// respond aborts responses that were already started, since they can't be
+// repaired, whether or not an error writer is set.
+func (c *config) respond(w *response.ResponseWriter, r *http.Request) {
+	if w.IsHeaderWritten {
+		panic(http.ErrAbortHandler)
+	}
+	if c.writeError == nil {
+		return
+	}
+	c.writeError(w, r, http.StatusInternalServerError, "")
+}
+
+func (c *config) isAbort(recovered any) bool {
+	for _, sentinel := range c.abortSentinels {
+		if isSentinel(recovered, sentinel) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSentinel(recovered any, sentinel any) bool {
+	recoveredErr, isErr := recovered.(error)
+	sentinelErr, isSentinelErr := sentinel.(error)
+	if isErr && isSentinelErr {
+		return errors.Is(recoveredErr, sentinelErr)
+	}
+
+	recoveredType := reflect.TypeOf(recovered)
+	return recoveredType == reflect.TypeOf(sentinel) &&
+		recoveredType.Comparable() &&
+		recovered == sentinel
+}
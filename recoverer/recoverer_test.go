@@ -2,42 +2,159 @@ package recoverer_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
+	"github.com/fivethirty/middest/errs"
 	"github.com/fivethirty/middest/internal/testhandler"
 	"github.com/fivethirty/middest/recoverer"
 )
 
 type testLogger struct {
 	logCount int
+	level    slog.Level
+	keys     []string
 }
 
-func (l *testLogger) Log(_ context.Context, _ slog.Level, _ string, _ ...any) {
+func (l *testLogger) Log(_ context.Context, level slog.Level, _ string, keysAndValues ...any) {
 	l.logCount++
+	l.level = level
+	l.keys = nil
+	for i := 0; i < len(keysAndValues); i++ {
+		switch v := keysAndValues[i].(type) {
+		case string:
+			l.keys = append(l.keys, v)
+			i++
+		case slog.Attr:
+			l.keys = append(l.keys, v.Key)
+		}
+	}
 }
 
+var errShutdown = errors.New("shutdown")
+
 func TestRecover(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name         string
-		handler      http.Handler
-		expectedLogs int
+		name          string
+		handler       http.Handler
+		opts          []recoverer.Option
+		expectedLogs  int
+		expectedCode  int
+		expectedBody  string
+		expectedPanic any
+		expectedLevel slog.Level
 	}{
 		{
 			name:         "no panic should return 200",
 			handler:      testhandler.New(t, http.StatusOK, 0),
 			expectedLogs: 0,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "panic without error writer should write nothing",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("oops!")
+			}),
+			expectedLogs:  1,
+			expectedCode:  http.StatusOK,
+			expectedLevel: slog.LevelError,
+		},
+		{
+			name: "panic with error writer should return 500",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("oops!")
+			}),
+			opts:          []recoverer.Option{recoverer.WithErrorWriter(errs.WritePlainText)},
+			expectedLogs:  1,
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  "Internal Server Error\n",
+			expectedLevel: slog.LevelError,
 		},
 		{
-			name: "panic should return 500",
+			name: "panic with plain text error should return 500",
 			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 				panic("oops!")
 			}),
-			expectedLogs: 1,
+			opts:          []recoverer.Option{recoverer.WithPlainTextError()},
+			expectedLogs:  1,
+			expectedCode:  http.StatusInternalServerError,
+			expectedBody:  "Internal Server Error\n",
+			expectedLevel: slog.LevelError,
+		},
+		{
+			name: "panic after write without error writer should abort",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("partial"))
+				panic("oops!")
+			}),
+			expectedLogs:  1,
+			expectedCode:  http.StatusOK,
+			expectedBody:  "partial",
+			expectedPanic: http.ErrAbortHandler,
+			expectedLevel: slog.LevelError,
+		},
+		{
+			name: "panic after write with error writer should abort",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("partial"))
+				panic("oops!")
+			}),
+			opts:          []recoverer.Option{recoverer.WithErrorWriter(errs.WritePlainText)},
+			expectedLogs:  1,
+			expectedCode:  http.StatusOK,
+			expectedBody:  "partial",
+			expectedPanic: http.ErrAbortHandler,
+			expectedLevel: slog.LevelError,
+		},
+		{
+			name: "ErrAbortHandler should be re-panicked",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(http.ErrAbortHandler)
+			}),
+			opts:          []recoverer.Option{recoverer.WithErrorWriter(errs.WritePlainText)},
+			expectedLogs:  1,
+			expectedCode:  http.StatusOK,
+			expectedPanic: http.ErrAbortHandler,
+			expectedLevel: slog.LevelInfo,
+		},
+		{
+			name: "wrapped error sentinel should abort",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(fmt.Errorf("closing: %w", errShutdown))
+			}),
+			opts:          []recoverer.Option{recoverer.WithAbortSentinels(errShutdown)},
+			expectedLogs:  1,
+			expectedCode:  http.StatusOK,
+			expectedPanic: http.ErrAbortHandler,
+			expectedLevel: slog.LevelInfo,
+		},
+		{
+			name: "value sentinel should abort",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("client gone")
+			}),
+			opts:          []recoverer.Option{recoverer.WithAbortSentinels("client gone")},
+			expectedLogs:  1,
+			expectedCode:  http.StatusOK,
+			expectedPanic: http.ErrAbortHandler,
+			expectedLevel: slog.LevelInfo,
+		},
+		{
+			name: "uncomparable panic value should not match sentinels",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic([]string{"oops!"})
+			}),
+			opts:          []recoverer.Option{recoverer.WithAbortSentinels("client gone")},
+			expectedLogs:  1,
+			expectedCode:  http.StatusOK,
+			expectedLevel: slog.LevelError,
 		},
 	}
 
@@ -45,14 +162,40 @@ func TestRecover(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 			logger := &testLogger{}
-			wrapped := recoverer.New(logger)(test.handler)
+			wrapped := recoverer.New(logger, test.opts...)(test.handler)
 			req := httptest.NewRequest(http.MethodGet, "/", nil)
 			w := httptest.NewRecorder()
-			wrapped.ServeHTTP(w, req)
+
+			var recovered any
+			func() {
+				defer func() {
+					recovered = recover()
+				}()
+				wrapped.ServeHTTP(w, req)
+			}()
 
 			if logger.logCount != test.expectedLogs {
 				t.Errorf("expected %d logs, got %d", test.expectedLogs, logger.logCount)
 			}
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+			if w.Body.String() != test.expectedBody {
+				t.Errorf("expected body %q, got %q", test.expectedBody, w.Body.String())
+			}
+			if recovered != test.expectedPanic {
+				t.Errorf("expected panic %v, got %v", test.expectedPanic, recovered)
+			}
+			if test.expectedLogs == 0 {
+				return
+			}
+			if logger.level != test.expectedLevel {
+				t.Errorf("expected log level %s, got %s", test.expectedLevel, logger.level)
+			}
+			hasTrace := slices.Contains(logger.keys, "trace")
+			if hasTrace != (test.expectedLevel == slog.LevelError) {
+				t.Errorf("expected trace only for errors, got keys %v", logger.keys)
+			}
 		})
 	}
 }
@@ -0,0 +1,106 @@
+package recoverer
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+const (
+	defaultStackDepth = 32
+	modulePath        = "github.com/fivethirty/middest/"
+)
+
+var packagePath = reflect.TypeFor[config]().PkgPath()
+
+func WithStackDepth(depth int) Option {
+	return func(c *config) {
+		c.stackDepth = depth
+	}
+}
+
+// stack returns the frames between the panic and the recoverer that caught
+// it, leaving out the runtime, net/http trampolines and middest middleware.
+func stack(depth int) []runtime.Frame {
+	pcs := make([]uintptr, 128)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	stack := []runtime.Frame{}
+	started := false
+	for {
+		frame, more := frames.Next()
+		function := frame.Function
+		switch {
+		case !started:
+			started = function == "runtime.gopanic"
+		case functionPackage(function) == packagePath || len(stack) == depth:
+			return stack
+		case !isTrimmed(function):
+			stack = append(stack, frame)
+		}
+		if !more {
+			return stack
+		}
+	}
+}
+
+func isTrimmed(function string) bool {
+	pkg := functionPackage(function)
+	switch {
+	case pkg == "runtime",
+		function == "net/http.HandlerFunc.ServeHTTP",
+		strings.HasPrefix(pkg, modulePath) && !strings.HasSuffix(pkg, "_test"):
+		return true
+	default:
+		return false
+	}
+}
+
+func functionPackage(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return function
+	}
+	return function[:slash+1+dot]
+}
+
+type stackFrame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+func stackAttr(frames []runtime.Frame) slog.Attr {
+	trace := make([]stackFrame, 0, len(frames))
+	for _, frame := range frames {
+		trace = append(trace, stackFrame{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		})
+	}
+	return slog.Any("trace", trace)
+}
+
+func panicAttrs(recovered any) []any {
+	attrs := []any{
+		"error", recovered,
+		"error_type", fmt.Sprintf("%T", recovered),
+	}
+
+	err, ok := recovered.(error)
+	if !ok {
+		return attrs
+	}
+	chain := []string{}
+	for err != nil {
+		chain = append(chain, fmt.Sprintf("%T: %v", err, err))
+		err = errors.Unwrap(err)
+	}
+	return append(attrs, "error_chain", chain)
+}
@@ -0,0 +1,96 @@
+package recoverer_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fivethirty/middest/ctxlog"
+	"github.com/fivethirty/middest/recoverer"
+)
+
+type frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+type panicEntry struct {
+	Error      any      `json:"error"`
+	ErrorType  string   `json:"error_type"`
+	ErrorChain []string `json:"error_chain"`
+	Trace      []frame  `json:"trace"`
+}
+
+func panicking(depth int) {
+	if depth == 0 {
+		panic(fmt.Errorf("loading: %w", errShutdown))
+	}
+	panicking(depth - 1)
+}
+
+func TestStack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		opts           []recoverer.Option
+		expectedFrames int
+	}{
+		{
+			name:           "default depth should include every handler frame",
+			expectedFrames: 6,
+		},
+		{
+			name:           "configured depth should limit frames",
+			opts:           []recoverer.Option{recoverer.WithStackDepth(2)},
+			expectedFrames: 2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := bytes.NewBuffer(nil)
+			logger := slog.New(slog.NewJSONHandler(buffer, nil))
+			handler := recoverer.New(logger, test.opts...)(
+				ctxlog.New(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					panicking(4)
+				})),
+			)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+			var entry panicEntry
+			if err := json.NewDecoder(buffer).Decode(&entry); err != nil {
+				t.Fatal(err)
+			}
+
+			if entry.ErrorType != "*fmt.wrapError" {
+				t.Errorf("expected error type *fmt.wrapError, got %s", entry.ErrorType)
+			}
+			if len(entry.ErrorChain) != 2 || !strings.Contains(entry.ErrorChain[1], "shutdown") {
+				t.Errorf("expected error chain to end with shutdown, got %v", entry.ErrorChain)
+			}
+			if len(entry.Trace) != test.expectedFrames {
+				t.Fatalf("expected %d frames, got %v", test.expectedFrames, entry.Trace)
+			}
+			for _, f := range entry.Trace {
+				if !strings.HasPrefix(f.Function, "github.com/fivethirty/middest/recoverer_test.") {
+					t.Errorf("expected only handler frames, got %s", f.Function)
+				}
+				if !strings.HasSuffix(f.File, "stack_test.go") || f.Line == 0 {
+					t.Errorf("expected file and line to be set, got %s:%d", f.File, f.Line)
+				}
+			}
+			if first := entry.Trace[0]; !strings.HasSuffix(first.Function, ".panicking") {
+				t.Errorf("expected first frame to be the panic site, got %s", first.Function)
+			}
+		})
+	}
+}
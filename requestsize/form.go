@@ -0,0 +1,286 @@
+package requestsize
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/fivethirty/middest/internal/response"
+)
+
+// FormLimits caps the shape of multipart and urlencoded bodies. Zero means
+// no limit.
+type FormLimits struct {
+	MaxParts           int
+	MaxFields          int
+	MaxFileSize        int64
+	MaxFieldSize       int64
+	MaxPartHeaderBytes int
+}
+
+type LimitError struct {
+	Limit  string
+	Max    int64
+	Status int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("requestsize: %s exceeds %d", e.Limit, e.Max)
+}
+
+// Form checks multipart and urlencoded bodies against limits as the handler
+// reads them, without buffering. Reads fail with a *LimitError once a limit
+// is broken, and if the handler hasn't written a response by then, Form
+// answers with its status.
+func Form(limits FormLimits) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			check := limits.checker(r)
+			if check == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			wrapped, ok := w.(*response.ResponseWriter)
+			if !ok {
+				wrapped = response.Wrap(w)
+			}
+			body := &formBody{ReadCloser: r.Body, check: check}
+			r.Body = body
+			defer func() {
+				_ = body.finish()
+			}()
+
+			next.ServeHTTP(wrapped, r)
+
+			var limitErr *LimitError
+			if errors.As(body.finish(), &limitErr) && !wrapped.IsHeaderWritten {
+				wrapped.WriteHeader(limitErr.Status)
+			}
+		})
+	}
+}
+
+func (l FormLimits) checker(r *http.Request) func(io.Reader) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil
+	}
+	switch mediaType {
+	case "multipart/form-data":
+		return func(body io.Reader) error {
+			return l.checkMultipart(multipart.NewReader(body, params["boundary"]))
+		}
+	case "application/x-www-form-urlencoded":
+		return l.checkURLEncoded
+	default:
+		return nil
+	}
+}
+
+// formBody copies what the handler reads into a pipe that check reads from
+// in its own goroutine.
+type formBody struct {
+	io.ReadCloser
+	check   func(io.Reader) error
+	pipe    *io.PipeWriter
+	done    chan struct{}
+	err     error
+	checked bool
+}
+
+func (b *formBody) Read(p []byte) (int, error) {
+	if b.pipe == nil {
+		b.start()
+	}
+	if err := b.limitErr(); err != nil {
+		return 0, err
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 && !b.checked {
+		if _, writeErr := b.pipe.Write(p[:n]); writeErr != nil {
+			b.checked = true
+			if err := b.limitErr(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err == io.EOF {
+		if err := b.finish(); err != nil {
+			return n, err
+		}
+	}
+	return n, err
+}
+
+func (b *formBody) Close() error {
+	_ = b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *formBody) start() {
+	reader, writer := io.Pipe()
+	b.pipe = writer
+	b.done = make(chan struct{})
+	go func() {
+		defer close(b.done)
+		err := b.check(reader)
+		_ = reader.CloseWithError(io.ErrClosedPipe)
+		var limitErr *LimitError
+		if errors.As(err, &limitErr) {
+			b.err = limitErr
+		}
+	}()
+}
+
+// limitErr reports a broken limit without waiting for check to finish.
+func (b *formBody) limitErr() error {
+	select {
+	case <-b.done:
+		return b.err
+	default:
+		return nil
+	}
+}
+
+// finish ends check and returns the *LimitError it found, if any.
+func (b *formBody) finish() error {
+	if b.pipe == nil {
+		return nil
+	}
+	_ = b.pipe.Close()
+	<-b.done
+	return b.err
+}
+
+func (l FormLimits) checkMultipart(reader *multipart.Reader) error {
+	parts, fields := 0, 0
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		parts++
+		if l.MaxParts > 0 && parts > l.MaxParts {
+			return &LimitError{
+				Limit:  "parts",
+				Max:    int64(l.MaxParts),
+				Status: http.StatusBadRequest,
+			}
+		}
+		if l.MaxPartHeaderBytes > 0 && headerBytes(part) > l.MaxPartHeaderBytes {
+			return &LimitError{
+				Limit:  "part header bytes",
+				Max:    int64(l.MaxPartHeaderBytes),
+				Status: http.StatusBadRequest,
+			}
+		}
+
+		limit, maxBytes := "file size", l.MaxFileSize
+		if part.FileName() == "" {
+			fields++
+			if l.MaxFields > 0 && fields > l.MaxFields {
+				return &LimitError{
+					Limit:  "fields",
+					Max:    int64(l.MaxFields),
+					Status: http.StatusBadRequest,
+				}
+			}
+			limit, maxBytes = "field size", l.MaxFieldSize
+		}
+		if err := checkSize(part, limit, maxBytes); err != nil {
+			return err
+		}
+	}
+}
+
+// checkURLEncoded measures the decoded size of field names and values as they
+// stream by, without holding on to them.
+func (l FormLimits) checkURLEncoded(body io.Reader) error {
+	reader := bufio.NewReader(body)
+	fields := 0
+	inField, inValue := false, false
+	var size int64
+	skip := 0
+	for {
+		c, err := reader.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if c == '&' {
+			inField, inValue, size, skip = false, false, 0, 0
+			continue
+		}
+
+		if !inField {
+			inField = true
+			fields++
+			if l.MaxFields > 0 && fields > l.MaxFields {
+				return &LimitError{
+					Limit:  "fields",
+					Max:    int64(l.MaxFields),
+					Status: http.StatusBadRequest,
+				}
+			}
+		}
+		switch {
+		case c == '=' && !inValue:
+			inValue, size, skip = true, 0, 0
+			continue
+		case skip > 0:
+			skip--
+			continue
+		case c == '%':
+			skip = 2
+		}
+		size++
+		if l.MaxFieldSize > 0 && size > l.MaxFieldSize {
+			return &LimitError{
+				Limit:  "field size",
+				Max:    l.MaxFieldSize,
+				Status: http.StatusRequestEntityTooLarge,
+			}
+		}
+	}
+}
+
+func checkSize(part io.Reader, limit string, maxBytes int64) error {
+	if maxBytes <= 0 {
+		_, err := io.Copy(io.Discard, part)
+		return err
+	}
+	n, err := io.Copy(io.Discard, io.LimitReader(part, maxBytes+1))
+	if err != nil {
+		return err
+	}
+	if n > maxBytes {
+		return &LimitError{Limit: limit, Max: maxBytes, Status: http.StatusRequestEntityTooLarge}
+	}
+	return nil
+}
+
+// headerBytes counts header lines as they were sent, with ": " and CRLF.
+func headerBytes(part *multipart.Part) int {
+	n := 0
+	for key, values := range part.Header {
+		for _, value := range values {
+			n += len(key) + len(value) + 4
+		}
+	}
+	return n
+}
@@ -0,0 +1,258 @@
+package requestsize_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/middest/requestsize"
+)
+
+type formPart struct {
+	name     string
+	filename string
+	header   string
+	content  string
+}
+
+func multipartBody(t *testing.T, parts ...formPart) (string, string) {
+	t.Helper()
+	var buffer bytes.Buffer
+	writer := multipart.NewWriter(&buffer)
+	for _, part := range parts {
+		header := textproto.MIMEHeader{}
+		disposition := `form-data; name="` + part.name + `"`
+		if part.filename != "" {
+			disposition += `; filename="` + part.filename + `"`
+		}
+		header.Set("Content-Disposition", disposition)
+		if part.header != "" {
+			header.Set("X-Extra", part.header)
+		}
+		w, err := writer.CreatePart(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.String(), writer.FormDataContentType()
+}
+
+func TestForm(t *testing.T) {
+	t.Parallel()
+
+	limits := requestsize.FormLimits{
+		MaxParts:           3,
+		MaxFields:          2,
+		MaxFileSize:        10,
+		MaxFieldSize:       5,
+		MaxPartHeaderBytes: 100,
+	}
+	file := formPart{name: "file", filename: "a.txt", content: "0123456789"}
+	field := formPart{name: "field", content: "01234"}
+
+	tests := []struct {
+		name          string
+		parts         []formPart
+		urlEncoded    string
+		expectedCode  int
+		expectedLimit string
+	}{
+		{
+			name:         "multipart within limits should pass",
+			parts:        []formPart{file, field, field},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:          "too many parts should return 400",
+			parts:         []formPart{file, file, field, field},
+			expectedCode:  http.StatusBadRequest,
+			expectedLimit: "parts",
+		},
+		{
+			name:          "too many multipart fields should return 400",
+			parts:         []formPart{field, field, field},
+			expectedCode:  http.StatusBadRequest,
+			expectedLimit: "fields",
+		},
+		{
+			name: "file too large should return 413",
+			parts: []formPart{
+				{name: "file", filename: "a.txt", content: "0123456789a"},
+			},
+			expectedCode:  http.StatusRequestEntityTooLarge,
+			expectedLimit: "file size",
+		},
+		{
+			name:          "multipart field too large should return 413",
+			parts:         []formPart{{name: "field", content: "012345"}},
+			expectedCode:  http.StatusRequestEntityTooLarge,
+			expectedLimit: "field size",
+		},
+		{
+			name: "part header too large should return 400",
+			parts: []formPart{
+				{name: "field", header: strings.Repeat("a", 100), content: "0"},
+			},
+			expectedCode:  http.StatusBadRequest,
+			expectedLimit: "part header bytes",
+		},
+		{
+			name:         "urlencoded within limits should pass",
+			urlEncoded:   "a=01234&b=%30",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:          "too many urlencoded fields should return 400",
+			urlEncoded:    "a=1&b=2&c=3",
+			expectedCode:  http.StatusBadRequest,
+			expectedLimit: "fields",
+		},
+		{
+			name:          "urlencoded field name too large should return 413",
+			urlEncoded:    "012345=a",
+			expectedCode:  http.StatusRequestEntityTooLarge,
+			expectedLimit: "field size",
+		},
+		{
+			name:         "escaped urlencoded field should be measured decoded",
+			urlEncoded:   "a=%30%31%32%33%34",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:          "urlencoded field too large should return 413",
+			urlEncoded:    "a=012345",
+			expectedCode:  http.StatusRequestEntityTooLarge,
+			expectedLimit: "field size",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			body, contentType := test.urlEncoded, "application/x-www-form-urlencoded"
+			if test.parts != nil {
+				body, contentType = multipartBody(t, test.parts...)
+			}
+
+			var read []byte
+			var readErr error
+			handler := requestsize.Form(limits)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					read, readErr = io.ReadAll(r.Body)
+				}),
+			)
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			r.Header.Set("Content-Type", contentType)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+			if test.expectedCode == http.StatusOK && string(read) != body {
+				t.Errorf("expected handler to read the full body, got %q", read)
+			}
+
+			var limitErr *requestsize.LimitError
+			if errors.As(readErr, &limitErr) {
+				if limitErr.Limit != test.expectedLimit {
+					t.Errorf("expected limit %q, got %q", test.expectedLimit, limitErr.Limit)
+				}
+			} else if readErr != nil || test.expectedLimit != "" {
+				t.Errorf("expected limit %q, got error %v", test.expectedLimit, readErr)
+			}
+		})
+	}
+}
+
+func TestFormMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	body, contentType := multipartBody(t, formPart{name: "field", content: "value"})
+	var readErr error
+	handler := requestsize.New(10)(requestsize.Form(requestsize.FormLimits{})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, readErr = io.ReadAll(r.Body)
+		}),
+	))
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(readErr, &maxBytesErr) {
+		t.Errorf("expected MaxBytesError, got %v", readErr)
+	}
+}
+
+func TestFormPartialRead(t *testing.T) {
+	t.Parallel()
+
+	body, contentType := multipartBody(t,
+		formPart{name: "file", filename: "a.txt", content: strings.Repeat("a", 1<<16)},
+	)
+	handler := requestsize.Form(requestsize.FormLimits{MaxFileSize: 1 << 20})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := r.Body.Read(make([]byte, 10)); err != nil {
+				t.Fatal(err)
+			}
+		}),
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestFormPanicStopsCheck(t *testing.T) {
+	body, contentType := multipartBody(t, formPart{name: "field", content: "value"})
+	handler := requestsize.Form(requestsize.FormLimits{MaxFields: 1})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := r.Body.Read(make([]byte, 10)); err != nil {
+				t.Fatal(err)
+			}
+			panic("oops!")
+		}),
+	)
+
+	before := runtime.NumGoroutine()
+	for range 10 {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		func() {
+			defer func() {
+				_ = recover()
+			}()
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("expected checks to stop after a panic, got %d goroutines from %d", after, before)
+	}
+}
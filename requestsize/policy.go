@@ -0,0 +1,148 @@
+package requestsize
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/fivethirty/middest/internal/route"
+)
+
+type contextKey string
+
+const limitKey contextKey = "request_size_limit"
+
+type Policy struct {
+	defaultLimit int64
+	routes       map[string]int64
+	methods      map[string]int64
+	contentTypes map[string]int64
+}
+
+// NewPolicy caps request bodies at defaultLimit unless a more specific rule
+// applies. Route rules win over content type rules, which win over method
+// rules.
+func NewPolicy(defaultLimit int64) *Policy {
+	return &Policy{
+		defaultLimit: defaultLimit,
+		routes:       map[string]int64{},
+		methods:      map[string]int64{},
+		contentTypes: map[string]int64{},
+	}
+}
+
+// Route sets the limit for requests matching the ServeMux pattern, e.g.
+// "POST /avatar".
+func (p *Policy) Route(pattern string, maxBytes int64) *Policy {
+	p.routes[pattern] = maxBytes
+	return p
+}
+
+func (p *Policy) Method(method string, maxBytes int64) *Policy {
+	p.methods[method] = maxBytes
+	return p
+}
+
+// ContentType sets the limit for a media type like "multipart/form-data" or
+// a whole type like "image/*".
+func (p *Policy) ContentType(contentType string, maxBytes int64) *Policy {
+	p.contentTypes[strings.ToLower(contentType)] = maxBytes
+	return p
+}
+
+// Middleware doesn't reject bodies up front, since handlers may change the
+// limit with SetLimit. Reading past the limit fails with an
+// *http.MaxBytesError instead.
+func (p *Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := &limit{maxBytes: p.limitFor(r, next)}
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = &limitedBody{
+				ReadCloser:    r.Body,
+				w:             w,
+				limit:         l,
+				contentLength: r.ContentLength,
+			}
+		}
+		ctx := context.WithValue(r.Context(), limitKey, l)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (p *Policy) limitFor(r *http.Request, next http.Handler) int64 {
+	if maxBytes, ok := p.routes[route.Pattern(r, next)]; ok {
+		return maxBytes
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil {
+		if maxBytes, ok := p.contentTypes[mediaType]; ok {
+			return maxBytes
+		}
+		typ, _, _ := strings.Cut(mediaType, "/")
+		if maxBytes, ok := p.contentTypes[typ+"/*"]; ok {
+			return maxBytes
+		}
+	}
+	if maxBytes, ok := p.methods[r.Method]; ok {
+		return maxBytes
+	}
+	return p.defaultLimit
+}
+
+// SetLimit changes the limit for the current request. It reports false if
+// the request didn't go through a Policy or its body has already been read.
+func SetLimit(r *http.Request, maxBytes int64) bool {
+	l, ok := r.Context().Value(limitKey).(*limit)
+	if !ok {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.reading {
+		return false
+	}
+	l.maxBytes = maxBytes
+	return true
+}
+
+func Limit(ctx context.Context) (int64, bool) {
+	l, ok := ctx.Value(limitKey).(*limit)
+	if !ok {
+		return 0, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.maxBytes, true
+}
+
+type limit struct {
+	mu       sync.Mutex
+	maxBytes int64
+	reading  bool
+}
+
+// limitedBody fixes the limit on the first Read.
+type limitedBody struct {
+	io.ReadCloser
+	w             http.ResponseWriter
+	limit         *limit
+	contentLength int64
+	reader        io.ReadCloser
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.reader == nil {
+		b.limit.mu.Lock()
+		b.limit.reading = true
+		maxBytes := b.limit.maxBytes
+		b.limit.mu.Unlock()
+
+		if b.contentLength > maxBytes {
+			return 0, &http.MaxBytesError{Limit: maxBytes}
+		}
+		b.reader = http.MaxBytesReader(b.w, b.ReadCloser, maxBytes)
+	}
+	return b.reader.Read(p)
+}
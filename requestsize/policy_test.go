@@ -0,0 +1,165 @@
+package requestsize_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fivethirty/middest/requestsize"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := requestsize.NewPolicy(10).
+		Method(http.MethodPut, 20).
+		ContentType("multipart/form-data", 30).
+		ContentType("image/*", 40).
+		Route("POST /avatar", 50)
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		contentType   string
+		setLimit      int64
+		expectedLimit int64
+	}{
+		{
+			name:          "request without rule should get default",
+			method:        http.MethodPost,
+			path:          "/items",
+			expectedLimit: 10,
+		},
+		{
+			name:          "method rule should apply",
+			method:        http.MethodPut,
+			path:          "/items",
+			expectedLimit: 20,
+		},
+		{
+			name:          "content type rule should win over method rule",
+			method:        http.MethodPut,
+			path:          "/items",
+			contentType:   "multipart/form-data; boundary=x",
+			expectedLimit: 30,
+		},
+		{
+			name:          "wildcard content type rule should apply",
+			method:        http.MethodPost,
+			path:          "/items",
+			contentType:   "image/png",
+			expectedLimit: 40,
+		},
+		{
+			name:          "route rule should win over content type rule",
+			method:        http.MethodPost,
+			path:          "/avatar",
+			contentType:   "image/png",
+			expectedLimit: 50,
+		},
+		{
+			name:          "handler should be able to raise limit",
+			method:        http.MethodPost,
+			path:          "/items",
+			setLimit:      100,
+			expectedLimit: 100,
+		},
+		{
+			name:          "handler should be able to lower limit",
+			method:        http.MethodPost,
+			path:          "/avatar",
+			setLimit:      5,
+			expectedLimit: 5,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, size := range []int64{test.expectedLimit, test.expectedLimit + 1} {
+				var limit int64
+				var readErr error
+				mux := http.NewServeMux()
+				handler := func(w http.ResponseWriter, r *http.Request) {
+					if test.setLimit != 0 && !requestsize.SetLimit(r, test.setLimit) {
+						t.Errorf("expected limit to be set")
+					}
+					limit, _ = requestsize.Limit(r.Context())
+					_, readErr = io.ReadAll(r.Body)
+				}
+				mux.HandleFunc("/items", handler)
+				mux.HandleFunc("POST /avatar", handler)
+
+				body := strings.NewReader(strings.Repeat("a", int(size)))
+				r := httptest.NewRequest(test.method, test.path, body)
+				if test.contentType != "" {
+					r.Header.Set("Content-Type", test.contentType)
+				}
+				policy.Middleware(mux).ServeHTTP(httptest.NewRecorder(), r)
+
+				if limit != test.expectedLimit {
+					t.Errorf("expected limit %d, got %d", test.expectedLimit, limit)
+				}
+				var maxBytesErr *http.MaxBytesError
+				expectedTooLarge := size > test.expectedLimit
+				if errors.As(readErr, &maxBytesErr) != expectedTooLarge {
+					t.Errorf("expected too large %v, got error %v", expectedTooLarge, readErr)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyContentLength(t *testing.T) {
+	t.Parallel()
+
+	var readErr error
+	handler := requestsize.NewPolicy(10).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, readErr = r.Body.Read(make([]byte, 1))
+		}),
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("short"))
+	r.ContentLength = 100
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(readErr, &maxBytesErr) {
+		t.Errorf("expected read to fail before reading the body, got %v", readErr)
+	}
+}
+
+func TestSetLimitAfterRead(t *testing.T) {
+	t.Parallel()
+
+	handler := requestsize.NewPolicy(10).Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := r.Body.Read(make([]byte, 1)); err != nil {
+				t.Fatal(err)
+			}
+			if requestsize.SetLimit(r, 100) {
+				t.Errorf("expected limit not to change after reading")
+			}
+		}),
+	)
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("body"))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+}
+
+func TestSetLimitWithoutPolicy(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("body"))
+	if requestsize.SetLimit(r, 100) {
+		t.Errorf("expected SetLimit to fail without a policy")
+	}
+	if _, ok := requestsize.Limit(r.Context()); ok {
+		t.Errorf("expected no limit without a policy")
+	}
+}
@@ -0,0 +1,210 @@
+package requestsize
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fivethirty/middest/internal/response"
+)
+
+const defaultRateWindow = 5 * time.Second
+
+// RateLimits bounds how long reading a body may take. Zero means no limit.
+type RateLimits struct {
+	ReadTimeout       time.Duration
+	MinBytesPerSecond int64
+	Window            time.Duration
+}
+
+type TimeoutError struct {
+	Limit     string
+	BytesRead int64
+	Elapsed   time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("requestsize: %s hit after %d bytes in %s", e.Limit, e.BytesRead, e.Elapsed)
+}
+
+// Rate fails body reads with a *TimeoutError once the body takes longer than
+// ReadTimeout or arrives slower than MinBytesPerSecond over a Window. Where
+// the connection supports read deadlines they are set too, so a client that
+// stops sending can't block a read. If the handler hasn't written a response
+// by then, Rate answers with a 408 and closes the connection.
+func Rate(logger *slog.Logger, limits RateLimits) func(http.Handler) http.Handler {
+	if limits.Window <= 0 {
+		limits.Window = defaultRateWindow
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			wrapped, ok := w.(*response.ResponseWriter)
+			if !ok {
+				wrapped = response.Wrap(w)
+			}
+			now := time.Now()
+			body := &rateBody{
+				ReadCloser:  r.Body,
+				controller:  http.NewResponseController(w),
+				limits:      limits,
+				start:       now,
+				windowStart: now,
+			}
+			if limits.ReadTimeout > 0 {
+				body.deadline = now.Add(limits.ReadTimeout)
+			}
+			r.Body = body
+
+			next.ServeHTTP(wrapped, r)
+			body.clearDeadline()
+
+			err := body.timeoutErr()
+			if err == nil {
+				return
+			}
+			logger.WarnContext(
+				r.Context(),
+				"Slow Request Body",
+				"limit", err.Limit,
+				"bytes_read", err.BytesRead,
+				"elapsed", err.Elapsed,
+			)
+			if !wrapped.IsHeaderWritten {
+				wrapped.Header().Set("Connection", "close")
+				wrapped.WriteHeader(http.StatusRequestTimeout)
+			}
+		})
+	}
+}
+
+type rateBody struct {
+	io.ReadCloser
+	controller  *http.ResponseController
+	limits      RateLimits
+	start       time.Time
+	deadline    time.Time
+	unsupported bool
+
+	mu           sync.Mutex
+	windowStart  time.Time
+	windowBytes  int64
+	bytesRead    int64
+	err          *TimeoutError
+	deadlineSet  bool
+	deadlineDone bool
+}
+
+func (b *rateBody) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.deadlineDone {
+		return b.ReadCloser.Read(p)
+	}
+	now := time.Now()
+	if !b.deadline.IsZero() && !now.Before(b.deadline) {
+		return 0, b.fail("read timeout", now)
+	}
+	b.setDeadline(now)
+
+	n, err := b.ReadCloser.Read(p)
+	now = time.Now()
+	b.bytesRead += int64(n)
+	b.windowBytes += int64(n)
+
+	switch {
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		limit := "upload rate"
+		if !b.deadline.IsZero() && !now.Before(b.deadline) {
+			limit = "read timeout"
+		}
+		return n, b.fail(limit, now)
+	case err == io.EOF:
+		b.clearDeadlineLocked()
+		return n, err
+	case err != nil:
+		return n, err
+	}
+
+	if b.limits.MinBytesPerSecond > 0 && now.Sub(b.windowStart) >= b.limits.Window {
+		minBytes := int64(float64(b.limits.MinBytesPerSecond) * b.limits.Window.Seconds())
+		if b.windowBytes < minBytes {
+			return n, b.fail("upload rate", now)
+		}
+		b.windowStart, b.windowBytes = now, 0
+	}
+	return n, nil
+}
+
+// setDeadline gives the next read until the end of the current window, so a
+// stalled client is cut off before it can hold up a read indefinitely.
+func (b *rateBody) setDeadline(now time.Time) {
+	if b.unsupported {
+		return
+	}
+	deadline := b.deadline
+	if b.limits.MinBytesPerSecond > 0 {
+		windowEnd := now.Add(b.limits.Window)
+		if deadline.IsZero() || windowEnd.Before(deadline) {
+			deadline = windowEnd
+		}
+	}
+	if deadline.IsZero() {
+		return
+	}
+	if err := b.controller.SetReadDeadline(deadline); err != nil {
+		b.unsupported = true
+		return
+	}
+	b.deadlineSet = true
+}
+
+// clearDeadlineLocked clears the read deadline so it can't cancel the
+// request once the body is done.
+func (b *rateBody) clearDeadlineLocked() {
+	b.deadlineDone = true
+	if b.deadlineSet {
+		_ = b.controller.SetReadDeadline(time.Time{})
+	}
+}
+
+func (b *rateBody) clearDeadline() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.clearDeadlineLocked()
+	}
+}
+
+// fail leaves the connection with an expired read deadline, so net/http
+// doesn't wait on the client while draining the rest of the body.
+func (b *rateBody) fail(limit string, now time.Time) *TimeoutError {
+	b.err = &TimeoutError{
+		Limit:     limit,
+		BytesRead: b.bytesRead,
+		Elapsed:   now.Sub(b.start),
+	}
+	if !b.unsupported {
+		_ = b.controller.SetReadDeadline(now)
+	}
+	return b.err
+}
+
+func (b *rateBody) timeoutErr() *TimeoutError {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
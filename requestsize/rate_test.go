@@ -0,0 +1,152 @@
+package requestsize_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/middest/requestsize"
+)
+
+type slowReader struct {
+	chunks int
+	delay  time.Duration
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	if s.chunks == 0 {
+		return 0, io.EOF
+	}
+	s.chunks--
+	time.Sleep(s.delay)
+	p[0] = 'a'
+	return 1, nil
+}
+
+func TestRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		limits        requestsize.RateLimits
+		body          io.Reader
+		expectedCode  int
+		expectedLimit string
+	}{
+		{
+			name: "fast body should pass",
+			limits: requestsize.RateLimits{
+				ReadTimeout:       time.Second,
+				MinBytesPerSecond: 100,
+				Window:            10 * time.Millisecond,
+			},
+			body:         strings.NewReader(strings.Repeat("a", 1000)),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "body slower than minimum rate should return 408",
+			limits: requestsize.RateLimits{
+				MinBytesPerSecond: 1000,
+				Window:            20 * time.Millisecond,
+			},
+			body:          &slowReader{chunks: 10, delay: 5 * time.Millisecond},
+			expectedCode:  http.StatusRequestTimeout,
+			expectedLimit: "upload rate",
+		},
+		{
+			name: "body over read timeout should return 408",
+			limits: requestsize.RateLimits{
+				ReadTimeout: 20 * time.Millisecond,
+			},
+			body:          &slowReader{chunks: 10, delay: 5 * time.Millisecond},
+			expectedCode:  http.StatusRequestTimeout,
+			expectedLimit: "read timeout",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buffer := bytes.NewBuffer(nil)
+			logger := slog.New(slog.NewJSONHandler(buffer, nil))
+			var readErr error
+			handler := requestsize.Rate(logger, test.limits)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					_, readErr = io.ReadAll(r.Body)
+				}),
+			)
+
+			r := httptest.NewRequest(http.MethodPost, "/", test.body)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+			var timeoutErr *requestsize.TimeoutError
+			if errors.As(readErr, &timeoutErr) {
+				if timeoutErr.Limit != test.expectedLimit {
+					t.Errorf("expected limit %q, got %q", test.expectedLimit, timeoutErr.Limit)
+				}
+			} else if readErr != nil || test.expectedLimit != "" {
+				t.Errorf("expected limit %q, got error %v", test.expectedLimit, readErr)
+			}
+			if hasLogs := buffer.Len() > 0; hasLogs != (test.expectedLimit != "") {
+				t.Errorf("expected logs %v, got %q", test.expectedLimit != "", buffer.String())
+			}
+		})
+	}
+}
+
+func TestRateStalledClient(t *testing.T) {
+	t.Parallel()
+
+	readErrs := make(chan error, 1)
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	server := httptest.NewServer(requestsize.Rate(logger, requestsize.RateLimits{
+		MinBytesPerSecond: 1,
+		Window:            50 * time.Millisecond,
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		readErrs <- err
+	})))
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	request := "POST / HTTP/1.1\r\nHost: example.com\r\nContent-Length: 100\r\n\r\npartial"
+	if _, err := conn.Write([]byte(request)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-readErrs:
+		var timeoutErr *requestsize.TimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Errorf("expected TimeoutError, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected stalled read to be cut off")
+	}
+
+	response, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(response), "HTTP/1.1 408") {
+		t.Errorf("expected 408 response, got %q", response)
+	}
+}
@@ -0,0 +1,91 @@
+package requestsize
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// RequestLimits caps everything but the body. Zero means no limit.
+type RequestLimits struct {
+	MaxURLLength   int
+	MaxQueryParams int
+	MaxQueryBytes  int
+	MaxHeaders     int
+	MaxCookieBytes int
+}
+
+func Request(limits RequestLimits) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var limitErr *LimitError
+			if errors.As(CheckRequest(r, limits), &limitErr) {
+				w.WriteHeader(limitErr.Status)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CheckRequest returns a *LimitError with a 414 for the URL and query limits
+// and a 431 for the header and cookie limits. It counts query parameters
+// without parsing them.
+func CheckRequest(r *http.Request, limits RequestLimits) error {
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+	if exceeds(len(uri), limits.MaxURLLength) {
+		return uriLimitError("url length", limits.MaxURLLength)
+	}
+	if exceeds(len(r.URL.RawQuery), limits.MaxQueryBytes) {
+		return uriLimitError("query bytes", limits.MaxQueryBytes)
+	}
+	if limits.MaxQueryParams > 0 && queryParams(r.URL.RawQuery) > limits.MaxQueryParams {
+		return uriLimitError("query params", limits.MaxQueryParams)
+	}
+
+	headers, cookieBytes := 0, 0
+	for key, values := range r.Header {
+		headers += len(values)
+		if key == "Cookie" {
+			for _, value := range values {
+				cookieBytes += len(value)
+			}
+		}
+	}
+	if exceeds(headers, limits.MaxHeaders) {
+		return headerLimitError("headers", limits.MaxHeaders)
+	}
+	if exceeds(cookieBytes, limits.MaxCookieBytes) {
+		return headerLimitError("cookie bytes", limits.MaxCookieBytes)
+	}
+	return nil
+}
+
+func exceeds(n, limit int) bool {
+	return limit > 0 && n > limit
+}
+
+func queryParams(rawQuery string) int {
+	n := 0
+	for param := range strings.SplitSeq(rawQuery, "&") {
+		if param != "" {
+			n++
+		}
+	}
+	return n
+}
+
+func uriLimitError(limit string, maxCount int) *LimitError {
+	return &LimitError{Limit: limit, Max: int64(maxCount), Status: http.StatusRequestURITooLong}
+}
+
+func headerLimitError(limit string, maxCount int) *LimitError {
+	return &LimitError{
+		Limit:  limit,
+		Max:    int64(maxCount),
+		Status: http.StatusRequestHeaderFieldsTooLarge,
+	}
+}
@@ -0,0 +1,109 @@
+package requestsize_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fivethirty/middest/internal/testhandler"
+	"github.com/fivethirty/middest/requestsize"
+)
+
+func TestRequest(t *testing.T) {
+	t.Parallel()
+
+	limits := requestsize.RequestLimits{
+		MaxURLLength:   100,
+		MaxQueryParams: 3,
+		MaxQueryBytes:  50,
+		MaxHeaders:     4,
+		MaxCookieBytes: 20,
+	}
+
+	tests := []struct {
+		name          string
+		target        string
+		headers       map[string][]string
+		expectedCode  int
+		expectedLimit string
+	}{
+		{
+			name:         "request within limits should pass",
+			target:       "/items?a=1&b=2&c=3",
+			headers:      map[string][]string{"Cookie": {"session=abc"}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:          "long url should return 414",
+			target:        "/" + strings.Repeat("a", 100),
+			expectedCode:  http.StatusRequestURITooLong,
+			expectedLimit: "url length",
+		},
+		{
+			name:          "large query should return 414",
+			target:        "/items?a=" + strings.Repeat("a", 50),
+			expectedCode:  http.StatusRequestURITooLong,
+			expectedLimit: "query bytes",
+		},
+		{
+			name:          "too many query params should return 414",
+			target:        "/items?a=1&a=2&b=3&c=4",
+			expectedCode:  http.StatusRequestURITooLong,
+			expectedLimit: "query params",
+		},
+		{
+			name:         "empty query params should not count",
+			target:       "/items?a=1&&b=2&c=3&",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "too many headers should return 431",
+			target: "/items",
+			headers: map[string][]string{
+				"X-One": {"1", "2"},
+				"X-Two": {"3", "4", "5"},
+			},
+			expectedCode:  http.StatusRequestHeaderFieldsTooLarge,
+			expectedLimit: "headers",
+		},
+		{
+			name:          "large cookies should return 431",
+			target:        "/items",
+			headers:       map[string][]string{"Cookie": {"a=0123456789", "b=0123456789"}},
+			expectedCode:  http.StatusRequestHeaderFieldsTooLarge,
+			expectedLimit: "cookie bytes",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, test.target, nil)
+			for key, values := range test.headers {
+				for _, value := range values {
+					r.Header.Add(key, value)
+				}
+			}
+			w := httptest.NewRecorder()
+			handler := requestsize.Request(limits)(testhandler.New(t, http.StatusOK, 0))
+			handler.ServeHTTP(w, r)
+
+			if w.Code != test.expectedCode {
+				t.Errorf("expected status code %d, got %d", test.expectedCode, w.Code)
+			}
+
+			err := requestsize.CheckRequest(r, limits)
+			var limitErr *requestsize.LimitError
+			if errors.As(err, &limitErr) {
+				if limitErr.Limit != test.expectedLimit {
+					t.Errorf("expected limit %q, got %q", test.expectedLimit, limitErr.Limit)
+				}
+			} else if err != nil || test.expectedLimit != "" {
+				t.Errorf("expected limit %q, got error %v", test.expectedLimit, err)
+			}
+		})
+	}
+}